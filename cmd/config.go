@@ -0,0 +1,470 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/selmison/seed-desafio-mercado-livre/mercadolivre"
+)
+
+// envPrefix prefixes the environment variables named after the flags:
+// -db-driver is read from ML_DB_DRIVER.
+const envPrefix = "ML_"
+
+// redacted replaces the secrets in the printed configuration.
+const redacted = "REDACTED"
+
+// placeholder stands for the secrets in the example configurations. It is
+// rejected, so that the examples cannot be deployed with a known secret.
+const placeholder = "CHANGE_ME"
+
+// settings holds what the configuration loader fills. Each setting is read,
+// from the lowest to the highest precedence, from its default, the YAML or
+// JSON file given by -config, its environment variable and its flag.
+type settings struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	BaseURL  string `yaml:"base_url"`
+	LogLevel string `yaml:"log_level"`
+	StandIns bool   `yaml:"stand_ins"`
+
+	DB struct {
+		Driver string `yaml:"driver"`
+		DSN    string `yaml:"dsn"`
+		// AutoMigrate applies the pending migrations on boot.
+		AutoMigrate bool `yaml:"auto_migrate"`
+	} `yaml:"db"`
+
+	// Metrics.Port zero disables the metrics listener.
+	Metrics struct {
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	} `yaml:"metrics"`
+
+	Server struct {
+		ReadTimeout       time.Duration `yaml:"read_timeout"`
+		ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+		WriteTimeout      time.Duration `yaml:"write_timeout"`
+		IdleTimeout       time.Duration `yaml:"idle_timeout"`
+		ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	} `yaml:"server"`
+
+	// Timeouts.Endpoints can only be set in the file.
+	Timeouts struct {
+		Default   time.Duration            `yaml:"default"`
+		Endpoints map[string]time.Duration `yaml:"endpoints"`
+	} `yaml:"timeouts"`
+
+	JWT struct {
+		Algorithm       string        `yaml:"algorithm"`
+		Secret          string        `yaml:"secret"`
+		PrivateKeyFile  string        `yaml:"private_key_file"`
+		KeyID           string        `yaml:"key_id"`
+		TTL             time.Duration `yaml:"ttl"`
+		RefreshWindow   time.Duration `yaml:"refresh_window"`
+		RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+	} `yaml:"jwt"`
+
+	Cookies struct {
+		Domain   string `yaml:"domain"`
+		HTTPOnly bool   `yaml:"http_only"`
+		Secure   bool   `yaml:"secure"`
+		SameSite string `yaml:"same_site"`
+	} `yaml:"cookies"`
+
+	// Gateways holds the secrets the payment gateways sign their callbacks with.
+	Gateways struct {
+		PagSeguroSecret string `yaml:"pagseguro_secret"`
+		PaypalSecret    string `yaml:"paypal_secret"`
+	} `yaml:"gateways"`
+
+	// SMTP.Addr and SMTP.From are required unless StandIns is set, when the
+	// mails default to the SMTP stand-in listening on SMTP.StandInAddr.
+	SMTP struct {
+		Addr        string        `yaml:"addr"`
+		From        string        `yaml:"from"`
+		Username    string        `yaml:"username"`
+		Password    string        `yaml:"password"`
+		Timeout     time.Duration `yaml:"timeout"`
+		StandInAddr string        `yaml:"stand_in_addr"`
+	} `yaml:"smtp"`
+
+	Storage struct {
+		Dir string `yaml:"dir"`
+		// URL defaults to BaseURL/images.
+		URL string `yaml:"url"`
+	} `yaml:"storage"`
+
+	Events struct {
+		Attempts int           `yaml:"attempts"`
+		Backoff  time.Duration `yaml:"backoff"`
+		// InvoiceURL and RankingURL are required unless StandIns is set,
+		// when they default to the stand-ins.
+		InvoiceURL string `yaml:"invoice_url"`
+		RankingURL string `yaml:"ranking_url"`
+	} `yaml:"events"`
+}
+
+// defaultSettings returns the settings used when nothing else is configured.
+func defaultSettings() *settings {
+	s := &settings{
+		Host:     "localhost",
+		Port:     3333,
+		BaseURL:  "http://localhost:3333",
+		LogLevel: "debug",
+	}
+	s.DB.Driver = "postgres"
+	s.Metrics.Host = "localhost"
+	s.Metrics.Port = 9464
+	s.Server.ShutdownTimeout = 30 * time.Second
+	s.Timeouts.Default = 5 * time.Second
+	s.Timeouts.Endpoints = map[string]time.Duration{
+		"ProductImagesPost": 30 * time.Second,
+	}
+	s.SMTP.StandInAddr = "localhost:2525"
+	s.Cookies.HTTPOnly = true
+	s.Cookies.SameSite = "lax"
+	s.Storage.Dir = "uploads"
+	return s
+}
+
+// flags binds every setting but Timeouts.Endpoints to a flag of fs.
+func (s *settings) flags(fs *flag.FlagSet) {
+	fs.StringVar(&s.Host, "host", s.Host, "network address to bind to")
+	fs.IntVar(&s.Port, "port", s.Port, "network port to bind to")
+	fs.StringVar(&s.BaseURL, "base-url", s.BaseURL, "public URL the server is reached at")
+	fs.StringVar(&s.LogLevel, "log-level", s.LogLevel, "log level: debug, info, warn or error")
+	fs.BoolVar(&s.StandIns, "stand-ins", s.StandIns, "mount the invoice and seller-ranking stand-ins")
+
+	fs.StringVar(&s.DB.Driver, "db-driver", s.DB.Driver, "database driver name")
+	fs.StringVar(&s.DB.DSN, "dsn", s.DB.DSN, "database data source name")
+	fs.BoolVar(&s.DB.AutoMigrate, "auto-migrate", s.DB.AutoMigrate, "apply the pending migrations on boot")
+
+	fs.StringVar(&s.Metrics.Host, "metrics-host", s.Metrics.Host, "network address the metrics listener binds to")
+	fs.IntVar(&s.Metrics.Port, "metrics-port", s.Metrics.Port, "network port the metrics listener binds to, 0 disabling it")
+
+	fs.DurationVar(&s.Server.ReadTimeout, "read-timeout", s.Server.ReadTimeout, "how long reading a request may take")
+	fs.DurationVar(&s.Server.ReadHeaderTimeout, "read-header-timeout", s.Server.ReadHeaderTimeout, "how long reading a request's headers may take")
+	fs.DurationVar(&s.Server.WriteTimeout, "write-timeout", s.Server.WriteTimeout, "how long handling a request may take")
+	fs.DurationVar(&s.Server.IdleTimeout, "idle-timeout", s.Server.IdleTimeout, "how long a keep-alive connection waits for the next request")
+	fs.DurationVar(&s.Server.ShutdownTimeout, "shutdown-timeout", s.Server.ShutdownTimeout, "how long the in-flight requests are given on shutdown")
+	fs.DurationVar(&s.Timeouts.Default, "timeout", s.Timeouts.Default, "default endpoint deadline")
+
+	fs.StringVar(&s.JWT.Algorithm, "jwt-algorithm", s.JWT.Algorithm, "token signing algorithm: HS256, RS256 or ES256")
+	fs.StringVar(&s.JWT.Secret, "jwt-secret", s.JWT.Secret, "HS256 signing key")
+	fs.StringVar(&s.JWT.PrivateKeyFile, "jwt-private-key-file", s.JWT.PrivateKeyFile, "PEM file holding the RS256 or ES256 signing key")
+	fs.StringVar(&s.JWT.KeyID, "jwt-key-id", s.JWT.KeyID, "kid header of the tokens")
+	fs.DurationVar(&s.JWT.TTL, "jwt-ttl", s.JWT.TTL, "how long a token is valid for")
+	fs.DurationVar(&s.JWT.RefreshWindow, "jwt-refresh-window", s.JWT.RefreshWindow, "how long before expiring a token can be refreshed")
+	fs.DurationVar(&s.JWT.RefreshTokenTTL, "jwt-refresh-token-ttl", s.JWT.RefreshTokenTTL, "how long a refresh token is valid for")
+
+	fs.StringVar(&s.Cookies.Domain, "cookie-domain", s.Cookies.Domain, "cookies' Domain attribute")
+	fs.BoolVar(&s.Cookies.HTTPOnly, "cookie-http-only", s.Cookies.HTTPOnly, "hide the token cookie from scripts")
+	fs.BoolVar(&s.Cookies.Secure, "cookie-secure", s.Cookies.Secure, "send the cookies only over HTTPS")
+	fs.StringVar(&s.Cookies.SameSite, "cookie-same-site", s.Cookies.SameSite, "cookies' SameSite attribute: default, lax, strict or none")
+
+	fs.StringVar(&s.Gateways.PagSeguroSecret, "pagseguro-secret", s.Gateways.PagSeguroSecret, "secret PagSeguro signs its callbacks with")
+	fs.StringVar(&s.Gateways.PaypalSecret, "paypal-secret", s.Gateways.PaypalSecret, "secret PayPal signs its callbacks with")
+
+	fs.StringVar(&s.SMTP.Addr, "smtp-addr", s.SMTP.Addr, "host:port of the SMTP server the mails are sent through (default the stand-in with -stand-ins)")
+	fs.StringVar(&s.SMTP.From, "smtp-from", s.SMTP.From, "sender of the mails")
+	fs.StringVar(&s.SMTP.Username, "smtp-username", s.SMTP.Username, "SMTP PLAIN authentication's user, none if empty")
+	fs.StringVar(&s.SMTP.Password, "smtp-password", s.SMTP.Password, "SMTP PLAIN authentication's password")
+	fs.DurationVar(&s.SMTP.Timeout, "smtp-timeout", s.SMTP.Timeout, "how long sending a mail may take")
+	fs.StringVar(&s.SMTP.StandInAddr, "smtp-stand-in-addr", s.SMTP.StandInAddr, "host:port the SMTP stand-in listens on")
+
+	fs.StringVar(&s.Storage.Dir, "storage-dir", s.Storage.Dir, "directory the uploaded files are stored in")
+	fs.StringVar(&s.Storage.URL, "storage-url", s.Storage.URL, "URL the uploaded files are served at (default base-url/images)")
+
+	fs.IntVar(&s.Events.Attempts, "event-attempts", s.Events.Attempts, "how many times an event handler is called before giving up")
+	fs.DurationVar(&s.Events.Backoff, "event-backoff", s.Events.Backoff, "delay before the first retry of an event handler")
+	fs.StringVar(&s.Events.InvoiceURL, "invoice-url", s.Events.InvoiceURL, "invoice system URL (default the stand-in with -stand-ins)")
+	fs.StringVar(&s.Events.RankingURL, "ranking-url", s.Events.RankingURL, "seller-ranking system URL (default the stand-in with -stand-ins)")
+}
+
+// loadSettings loads the settings from the defaults, the -config file, the
+// environment and args, in increasing precedence, and validates them. The
+// arguments following the flags are returned.
+func loadSettings(args []string) (*settings, []string, error) {
+	s := defaultSettings()
+	fs := flag.NewFlagSet("mercadolivre", flag.ContinueOnError)
+	s.flags(fs)
+	configFile := fs.String("config", os.Getenv(envPrefix+"CONFIG"), "YAML or JSON configuration file")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	// The flags were written into s, so they are kept to be applied last.
+	setFlags := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = f.Value.String()
+	})
+
+	if *configFile != "" {
+		file := defaultSettings()
+		if err := file.readFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+		*s = *file
+	}
+
+	var errs []string
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		name := envName(f.Name)
+		if v, ok := os.LookupEnv(name); ok {
+			if err := fs.Set(f.Name, v); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+	})
+	for name, v := range setFlags {
+		if err := fs.Set(name, v); err != nil {
+			errs = append(errs, fmt.Sprintf("-%s: %v", name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, fmt.Errorf("config: %s", strings.Join(errs, "; "))
+	}
+
+	s.applyDerivedDefaults()
+	var command string
+	if fs.NArg() > 0 {
+		command = fs.Arg(0)
+	}
+	if err := s.validate(command); err != nil {
+		return nil, nil, err
+	}
+	return s, fs.Args(), nil
+}
+
+// envName returns the environment variable of the flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// readFile reads the YAML or JSON file into s. JSON being YAML, both are
+// decoded alike; unknown keys are rejected. The file's endpoint timeouts,
+// if any, replace those of s.
+func (s *settings) readFile(name string) error {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	endpoints := s.Timeouts.Endpoints
+	s.Timeouts.Endpoints = nil
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return fmt.Errorf("config: %s: %w", name, err)
+	}
+	if s.Timeouts.Endpoints == nil {
+		s.Timeouts.Endpoints = endpoints
+	}
+	return nil
+}
+
+// applyDerivedDefaults fills the URLs defaulting to BaseURL. The invoice and
+// seller-ranking URLs only default to the stand-ins when they are mounted.
+func (s *settings) applyDerivedDefaults() {
+	baseURL := strings.TrimSuffix(s.BaseURL, "/")
+	if s.Storage.URL == "" {
+		s.Storage.URL = baseURL + "/images"
+	}
+	if !s.StandIns {
+		return
+	}
+	if s.SMTP.Addr == "" {
+		s.SMTP.Addr = s.SMTP.StandInAddr
+	}
+	if s.SMTP.From == "" {
+		s.SMTP.From = "mercadolivre@localhost"
+	}
+	if s.Events.InvoiceURL == "" {
+		s.Events.InvoiceURL = baseURL + "/stand-ins/invoices"
+	}
+	if s.Events.RankingURL == "" {
+		s.Events.RankingURL = baseURL + "/stand-ins/rankings"
+	}
+}
+
+var (
+	logLevels = map[string]mercadolivre.Level{
+		"debug": mercadolivre.DebugLevel,
+		"info":  mercadolivre.InfoLevel,
+		"warn":  mercadolivre.WarnLevel,
+		"error": mercadolivre.ErrorLevel,
+	}
+	sameSites = map[string]http.SameSite{
+		"default": http.SameSiteDefaultMode,
+		"lax":     http.SameSiteLaxMode,
+		"strict":  http.SameSiteStrictMode,
+		"none":    http.SameSiteNoneMode,
+	}
+)
+
+// validate reports every missing or invalid setting the command needs: the
+// migrate subcommand only needs the log level and the DB, serving needs all.
+func (s *settings) validate(command string) error {
+	var errs []string
+	required := func(name, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Sprintf("%s (-%s or %s) is required", name, name, envName(name)))
+		}
+	}
+	secret := func(name, value string) {
+		if value == placeholder {
+			errs = append(errs, fmt.Sprintf("%s should be set to a secret of your own, not the %s placeholder", name, placeholder))
+		} else {
+			required(name, value)
+		}
+	}
+	absoluteURL := func(name, value string) {
+		if u, err := url.Parse(value); err != nil || !u.IsAbs() || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("%s %q should be an absolute URL", name, value))
+		}
+	}
+	requiredURL := func(name, value string) {
+		if value == "" {
+			required(name, value)
+		} else {
+			absoluteURL(name, value)
+		}
+	}
+
+	if _, ok := logLevels[s.LogLevel]; !ok {
+		errs = append(errs, fmt.Sprintf("log-level %q should be debug, info, warn or error", s.LogLevel))
+	}
+	required("db-driver", s.DB.Driver)
+	required("dsn", s.DB.DSN)
+	if command == "migrate" {
+		if len(errs) > 0 {
+			return errors.New("config: " + strings.Join(errs, "; "))
+		}
+		return nil
+	}
+
+	if s.Port <= 0 || s.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("port %d should be between 1 and 65535", s.Port))
+	}
+	if s.Metrics.Port < 0 || s.Metrics.Port > 65535 || s.Metrics.Port == s.Port {
+		errs = append(errs, fmt.Sprintf("metrics-port %d should be 0 or between 1 and 65535, other than port", s.Metrics.Port))
+	}
+	absoluteURL("base-url", s.BaseURL)
+	switch s.JWT.Algorithm {
+	case "", "HS256":
+		secret("jwt-secret", s.JWT.Secret)
+	case "RS256", "ES256":
+		required("jwt-private-key-file", s.JWT.PrivateKeyFile)
+	default:
+		errs = append(errs, fmt.Sprintf("jwt-algorithm %q should be HS256, RS256 or ES256", s.JWT.Algorithm))
+	}
+	if _, ok := sameSites[s.Cookies.SameSite]; !ok {
+		errs = append(errs, fmt.Sprintf("cookie-same-site %q should be default, lax, strict or none", s.Cookies.SameSite))
+	}
+	secret("pagseguro-secret", s.Gateways.PagSeguroSecret)
+	secret("paypal-secret", s.Gateways.PaypalSecret)
+	required("smtp-from", s.SMTP.From)
+	if s.SMTP.Addr == "" {
+		required("smtp-addr", s.SMTP.Addr)
+	} else if _, _, err := net.SplitHostPort(s.SMTP.Addr); err != nil {
+		errs = append(errs, fmt.Sprintf("smtp-addr %q should be host:port", s.SMTP.Addr))
+	}
+	required("storage-dir", s.Storage.Dir)
+	absoluteURL("storage-url", s.Storage.URL)
+	requiredURL("invoice-url", s.Events.InvoiceURL)
+	requiredURL("ranking-url", s.Events.RankingURL)
+
+	if len(errs) > 0 {
+		return errors.New("config: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// logLevel returns the validated log level.
+func (s *settings) logLevel() mercadolivre.Level {
+	return logLevels[s.LogLevel]
+}
+
+// config returns the mercadolivre.Config holding s. The dependencies built
+// from the settings, such as the DB and the storage, are left to the caller.
+func (s *settings) config() mercadolivre.Config {
+	return mercadolivre.Config{
+		Host:       s.Host,
+		Port:       s.Port,
+		BaseURL:    s.BaseURL,
+		DriverName: s.DB.Driver,
+		MetricsServer: mercadolivre.MetricsServerConfig{
+			Host: s.Metrics.Host,
+			Port: s.Metrics.Port,
+		},
+		Server: mercadolivre.ServerConfig{
+			ReadTimeout:       s.Server.ReadTimeout,
+			ReadHeaderTimeout: s.Server.ReadHeaderTimeout,
+			WriteTimeout:      s.Server.WriteTimeout,
+			IdleTimeout:       s.Server.IdleTimeout,
+			ShutdownTimeout:   s.Server.ShutdownTimeout,
+		},
+		JWT: mercadolivre.JWTConfig{
+			Algorithm:       s.JWT.Algorithm,
+			Secret:          s.JWT.Secret,
+			PrivateKeyFile:  s.JWT.PrivateKeyFile,
+			KeyID:           s.JWT.KeyID,
+			TTL:             s.JWT.TTL,
+			RefreshWindow:   s.JWT.RefreshWindow,
+			RefreshTokenTTL: s.JWT.RefreshTokenTTL,
+		},
+		Cookies: mercadolivre.CookieConfig{
+			Domain:   s.Cookies.Domain,
+			HTTPOnly: s.Cookies.HTTPOnly,
+			Secure:   s.Cookies.Secure,
+			SameSite: sameSites[s.Cookies.SameSite],
+		},
+		GatewaySecrets: map[mercadolivre.Gateway]string{
+			mercadolivre.GatewayPagSeguro: s.Gateways.PagSeguroSecret,
+			mercadolivre.GatewayPaypal:    s.Gateways.PaypalSecret,
+		},
+		Timeouts: mercadolivre.TimeoutConfig{
+			Default:   s.Timeouts.Default,
+			Endpoints: s.Timeouts.Endpoints,
+		},
+		EventAttempts: s.Events.Attempts,
+		EventBackoff:  s.Events.Backoff,
+		StandIns:      s.StandIns,
+	}
+}
+
+// dsnPassword matches the password of a key=value DSN.
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(\\.|[^'])*'|\S+)`)
+
+// String returns s as YAML, with the secrets redacted.
+func (s settings) String() string {
+	for _, secret := range []*string{&s.JWT.Secret, &s.Gateways.PagSeguroSecret, &s.Gateways.PaypalSecret, &s.SMTP.Password} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	if u, err := url.Parse(s.DB.DSN); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		s.DB.DSN = u.String()
+	} else {
+		s.DB.DSN = dsnPassword.ReplaceAllString(s.DB.DSN, "${1}"+redacted)
+	}
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
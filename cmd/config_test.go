@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadSettingsMigrateNeedsOnlyTheDB(t *testing.T) {
+	if _, args, err := loadSettings([]string{"-dsn", "postgres://localhost/db", "migrate", "status"}); err != nil {
+		t.Fatalf("loadSettings error = %v, want nil", err)
+	} else if strings.Join(args, " ") != "migrate status" {
+		t.Fatalf("loadSettings args = %v, want migrate status", args)
+	}
+
+	_, _, err := loadSettings([]string{"-dsn", "postgres://localhost/db"})
+	if err == nil || !strings.Contains(err.Error(), "jwt-secret") {
+		t.Fatalf("loadSettings error = %v, want the jwt-secret required", err)
+	}
+}
+
+func TestLoadSettingsRejectsThePlaceholders(t *testing.T) {
+	_, _, err := loadSettings([]string{"-config", "../config.example.yaml"})
+	if err == nil {
+		t.Fatal("loadSettings error = nil, want the placeholders rejected")
+	}
+	for _, name := range []string{"jwt-secret", "pagseguro-secret", "paypal-secret"} {
+		if !strings.Contains(err.Error(), name+" should be set to a secret of your own") {
+			t.Errorf("loadSettings error = %v, want %s rejected", err, name)
+		}
+	}
+
+	args := []string{"-config", "../config.example.yaml", "-jwt-secret", "s1", "-pagseguro-secret", "s2", "-paypal-secret", "s3"}
+	if _, _, err := loadSettings(args); err != nil {
+		t.Fatalf("loadSettings error = %v, want nil", err)
+	}
+}
@@ -1,34 +1,133 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/selmison/seed-desafio-mercado-livre/mercadolivre"
+	"github.com/selmison/seed-desafio-mercado-livre/migrations"
 )
 
 func main() {
-	logger := mercadolivre.NewLogger(mercadolivre.DebugLevel)
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves as configured by args and the environment until SIGINT or
+// SIGTERM is received. The in-flight requests are then drained, the DB pool
+// closed and the logger flushed. If the flags are followed by the migrate
+// subcommand, it is run instead.
+func run(args []string) error {
+	settings, args, err := loadSettings(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	logger := mercadolivre.NewLogger(settings.logLevel())
+	defer syncLogger(logger)
+	logger.Infof("effective configuration:\n%s", settings)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Infof("received %s, shutting down", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	db, err := sql.Open(settings.DB.Driver, settings.DB.DSN)
+	if err != nil {
+		return fmt.Errorf("failed to initialize db: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Errorf("failed to close db: %v", err)
+		}
+	}()
+	migrator, err := mercadolivre.NewMigrator(db, migrations.FS, logger)
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrator: %w", err)
+	}
+	if len(args) > 0 {
+		if args[0] != "migrate" {
+			return fmt.Errorf("unknown command %q\n%s", args[0], migrateUsage)
+		}
+		return runMigrate(ctx, migrator, args[1:], os.Stdout)
+	}
+	if settings.DB.AutoMigrate {
+		if err := migrator.Up(ctx, 0); err != nil {
+			return fmt.Errorf("failed to migrate db: %w", err)
+		}
+	}
 
-	driverName := "postgres"
-	db, err := sql.Open(driverName, "host=localhost port=5433 dbname=mercadolivre user=postgres password=postgres sslmode=disable")
+	storage, err := mercadolivre.NewLocalStorage(settings.Storage.Dir, settings.Storage.URL)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	cfg := settings.config()
+	cfg.DB = db
+	cfg.Storage = storage
+	if settings.StandIns && settings.SMTP.Addr == settings.SMTP.StandInAddr {
+		ln, err := net.Listen("tcp", settings.SMTP.StandInAddr)
+		if err != nil {
+			return fmt.Errorf("failed to start the SMTP stand-in: %w", err)
+		}
+		defer ln.Close()
+		go mercadolivre.NewSMTPStandIn(mercadolivre.NewFakeMailer(logger), logger).Serve(ln)
+	}
+	cfg.Mailer, err = mercadolivre.NewSMTPMailer(mercadolivre.SMTPConfig{
+		Addr:     settings.SMTP.Addr,
+		From:     settings.SMTP.From,
+		Username: settings.SMTP.Username,
+		Password: settings.SMTP.Password,
+		Timeout:  settings.SMTP.Timeout,
+	})
 	if err != nil {
-		log.Fatalf("failed to initialize db: %v\n", err)
+		return fmt.Errorf("failed to initialize mailer: %w", err)
 	}
-	cfg := mercadolivre.Config{
-		Host:       "localhost",
-		Port:       3333,
-		DriverName: driverName,
-		DB:         db,
+	cfg.Metrics = mercadolivre.NewPrometheusMetrics(db)
+	cfg.PurchaseConfirmedHandlers = []mercadolivre.PurchaseConfirmedHandler{
+		mercadolivre.NewInvoiceHandler(settings.Events.InvoiceURL, httpClient),
+		mercadolivre.NewRankingHandler(settings.Events.RankingURL, httpClient),
 	}
 
 	svc, err := mercadolivre.NewService(cfg, logger)
 	if err != nil {
-		log.Fatalf("failed to initialize service: %v\n", err)
+		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
-	if err := mercadolivre.NewHTTPServer(cfg, svc, logger); err != nil {
-		log.Fatal(fmt.Sprintf("Error starting http server: %s", err))
+	if err := mercadolivre.NewHTTPServer(ctx, cfg, svc, logger); err != nil {
+		return fmt.Errorf("error running http server: %w", err)
+	}
+	return nil
+}
+
+// syncLogger flushes logger. Syncing a terminal fails on some systems,
+// which is ignored.
+func syncLogger(logger mercadolivre.Logger) {
+	err := logger.Sync()
+	if err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
+		log.Printf("failed to flush logger: %v", err)
 	}
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/selmison/seed-desafio-mercado-livre/mercadolivre"
+)
+
+const migrateUsage = `usage: migrate up [N] | down [N] | status | goto V | force V
+  up [N]    apply the next N pending migrations, all of them by default
+  down [N]  revert the last N applied migrations, 1 by default
+  status    list the migrations and the applied version
+  goto V    apply or revert the migrations up to version V, 0 reverting all
+  force V   record V as the applied version without running any migration`
+
+// runMigrate runs the migrate subcommand args, writing the status to w.
+func runMigrate(ctx context.Context, migrator *mercadolivre.Migrator, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate: missing command\n%s", migrateUsage)
+	}
+	command, args := args[0], args[1:]
+	if len(args) > 1 {
+		return fmt.Errorf("migrate %s: too many arguments\n%s", command, migrateUsage)
+	}
+	arg := func(required bool) (int, error) {
+		if len(args) == 0 {
+			if required {
+				return 0, fmt.Errorf("migrate %s: missing version\n%s", command, migrateUsage)
+			}
+			return 0, nil
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("migrate %s: %q should be a non-negative number", command, args[0])
+		}
+		return n, nil
+	}
+
+	switch command {
+	case "up":
+		n, err := arg(false)
+		if err != nil {
+			return err
+		}
+		return migrator.Up(ctx, n)
+	case "down":
+		n, err := arg(false)
+		if err != nil {
+			return err
+		}
+		return migrator.Down(ctx, n)
+	case "goto":
+		v, err := arg(true)
+		if err != nil {
+			return err
+		}
+		return migrator.Goto(ctx, uint(v))
+	case "force":
+		v, err := arg(true)
+		if err != nil {
+			return err
+		}
+		return migrator.Force(ctx, uint(v))
+	case "status":
+		if len(args) > 0 {
+			return fmt.Errorf("migrate status: too many arguments\n%s", migrateUsage)
+		}
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(w, status)
+	default:
+		return fmt.Errorf("migrate: unknown command %q\n%s", command, migrateUsage)
+	}
+}
+
+func printMigrationStatus(w io.Writer, status *mercadolivre.MigrationStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE")
+	for _, m := range status.Migrations {
+		state := "pending"
+		switch {
+		case m.Version == status.Version && status.Dirty:
+			state = "dirty"
+		case m.Version <= status.Version:
+			state = "applied"
+		}
+		fmt.Fprintf(tw, "%06d\t%s\t%s\n", m.Version, m.Name, state)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "schema version: %d\n", status.Version)
+	return err
+}
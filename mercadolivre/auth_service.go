@@ -2,12 +2,12 @@ package mercadolivre
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	jwtKit "github.com/go-kit/kit/auth/jwt"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
@@ -19,29 +19,24 @@ type AuthRequest struct {
 }
 
 // Validate validates AuthRequest.
-func (a AuthRequest) Validate() error {
-	return Validate(a)
+func (a AuthRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, a)
 }
 
 type AuthResponse struct {
-	TknStr    string `json:"token"`
-	ExpiresAt time.Time
+	TknStr           string     `json:"token"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RefreshToken     string     `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"`
 }
 
 // Auth authenticates a user.
 func (s *service) Auth(ctx context.Context, req AuthRequest) (*AuthResponse, error) {
-	stmt, err := s.db.Preparex(`SELECT * FROM users WHERE name=$1`)
 	msgError := "service.auth"
-	if err != nil {
-		err := fmt.Errorf("%w: %v", ErrInternalServer, err)
-		return nil, errors.Wrap(err, msgError)
-	}
-
-	user := User{}
-	err = stmt.QueryRowx(req.UserName).StructScan(&user)
+	user, err := s.repo.UserByName(ctx, req.UserName)
 	errAuthFailed := fmt.Errorf("%w: %s's credentials are not correct", ErrAuthFailed, req.UserName)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, ErrNotFound) {
 			return nil, errors.Wrap(errAuthFailed, msgError)
 		}
 		err := fmt.Errorf("%w: %v", ErrInternalServer, err)
@@ -53,23 +48,29 @@ func (s *service) Auth(ctx context.Context, req AuthRequest) (*AuthResponse, err
 	}
 
 	var response *AuthResponse
-	response, err = createToken(user.ID, "myJWTSecretKey")
+	response, err = s.createToken(user.ID)
+	if err != nil {
+		err := fmt.Errorf("%w: %v", ErrInternalServer, err)
+		return nil, errors.Wrap(err, msgError)
+	}
+	_, refreshToken, refreshExpiresAt, err := s.issueRefreshToken(ctx, user.ID, uuid.New().String())
 	if err != nil {
 		err := fmt.Errorf("%w: %v", ErrInternalServer, err)
 		return nil, errors.Wrap(err, msgError)
 	}
+	response.RefreshToken = refreshToken
+	response.RefreshExpiresAt = &refreshExpiresAt
 	return response, nil
 }
 
-func createToken(userID, jwtSecretKey string) (*AuthResponse, error) {
-	var err error
-	expiresAt := time.Now().Add(time.Minute * 5)
+// createToken creates a signed token for the user.
+func (s *service) createToken(userID string) (*AuthResponse, error) {
+	expiresAt := time.Now().Add(s.jwt.ttl)
 	claims := jwt.StandardClaims{
 		ExpiresAt: expiresAt.Unix(),
 		Id:        userID,
 	}
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tknStr, err := at.SignedString([]byte(jwtSecretKey))
+	tknStr, err := s.jwt.sign(claims)
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +80,17 @@ func createToken(userID, jwtSecretKey string) (*AuthResponse, error) {
 	}, nil
 }
 
-// ReAuth reauthenticates a user.
+// userIDFrom returns the authenticated user's ID from the JWT claims in ctx.
+func userIDFrom(ctx context.Context) (string, error) {
+	claims, ok := ctx.Value(jwtKit.JWTClaimsContextKey).(*jwt.StandardClaims)
+	if !ok || claims.Id == "" {
+		return "", fmt.Errorf("%w: %v", ErrAuthFailed, "token should have the user's id")
+	}
+	return claims.Id, nil
+}
+
+// ReAuth reauthenticates a user whose token expires within the refresh
+// window. Past it, the token is renewed with a refresh token, by Refresh.
 func (s *service) ReAuth(ctx context.Context) (*AuthResponse, error) {
 	msgError := "service.re_auth"
 
@@ -94,9 +105,7 @@ func (s *service) ReAuth(ctx context.Context) (*AuthResponse, error) {
 
 	claims := &jwt.StandardClaims{}
 	var tkn *jwt.Token
-	tkn, err := jwt.ParseWithClaims(tknStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte("myJWTSecretKey"), nil
-	})
+	tkn, err := jwt.ParseWithClaims(tknStr, claims, s.jwt.keyFunc)
 	if err != nil {
 		if err == jwt.ErrSignatureInvalid {
 			return nil, errors.Wrap(fmt.Errorf("%w: %v", ErrAuthFailed, err), msgError)
@@ -112,19 +121,18 @@ func (s *service) ReAuth(ctx context.Context) (*AuthResponse, error) {
 	}
 
 	until := time.Until(time.Unix(claims.ExpiresAt, 0))
-	if until > 30*time.Second {
+	if until > s.jwt.refreshWindow {
 		return nil, ValidationErrorsResponse{
 			&ValidationErrorResponse{
-				Condition:   "elapsed_time should be less than 30s",
+				Condition:   fmt.Sprintf("elapsed_time should be less than %s", s.jwt.refreshWindow),
 				ActualValue: until.String(),
 			},
 		}
 	}
 
-	expiresAt := time.Now().Add(5 * time.Minute)
+	expiresAt := time.Now().Add(s.jwt.ttl)
 	claims.ExpiresAt = expiresAt.Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tknStr, err = token.SignedString([]byte("myJWTSecretKey"))
+	tknStr, err = s.jwt.sign(claims)
 	if err != nil {
 		err := fmt.Errorf("%w: %v", ErrInternalServer, err)
 		return nil, errors.Wrap(err, msgError)
@@ -135,3 +143,8 @@ func (s *service) ReAuth(ctx context.Context) (*AuthResponse, error) {
 		ExpiresAt: expiresAt,
 	}, nil
 }
+
+// JWKS returns the public keys used to verify the tokens.
+func (s *service) JWKS(ctx context.Context) (*JWKS, error) {
+	return s.jwt.jwks(), nil
+}
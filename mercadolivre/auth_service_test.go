@@ -0,0 +1,80 @@
+package mercadolivre
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	jwtKit "github.com/go-kit/kit/auth/jwt"
+)
+
+func TestAuth(t *testing.T) {
+	svc, _ := newTestService(t)
+	userID := createTestUser(t, svc, "user@example.com", "secret123")
+
+	res, err := svc.Auth(context.Background(), AuthRequest{UserName: "user@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+	if !res.ExpiresAt.After(time.Now()) {
+		t.Errorf("ExpiresAt = %v, want in the future", res.ExpiresAt)
+	}
+	if res.RefreshToken == "" || res.RefreshExpiresAt == nil {
+		t.Errorf("RefreshToken = %q, RefreshExpiresAt = %v, want both", res.RefreshToken, res.RefreshExpiresAt)
+	}
+
+	claims := &jwt.StandardClaims{}
+	if _, err := jwt.ParseWithClaims(res.TknStr, claims, svc.jwt.keyFunc); err != nil {
+		t.Fatalf("parsing the token: %v", err)
+	}
+	if claims.Id != userID {
+		t.Errorf("token's Id = %q, want %q", claims.Id, userID)
+	}
+}
+
+func TestAuthFailed(t *testing.T) {
+	svc, _ := newTestService(t)
+	createTestUser(t, svc, "user@example.com", "secret123")
+
+	tests := []struct {
+		name string
+		req  AuthRequest
+	}{
+		{"wrong password", AuthRequest{UserName: "user@example.com", Password: "wrong123"}},
+		{"unknown user", AuthRequest{UserName: "nobody@example.com", Password: "secret123"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Auth(context.Background(), tt.req)
+			if !errors.Is(err, ErrAuthFailed) {
+				t.Fatalf("Auth error = %v, want %v", err, ErrAuthFailed)
+			}
+		})
+	}
+}
+
+func TestReAuth(t *testing.T) {
+	svc, _ := newTestService(t)
+	createTestUser(t, svc, "user@example.com", "secret123")
+	res, err := svc.Auth(context.Background(), AuthRequest{UserName: "user@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("Auth: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), jwtKit.JWTTokenContextKey, res.TknStr)
+
+	var errs ValidationErrorsResponse
+	if _, err := svc.ReAuth(ctx); !errors.As(err, &errs) {
+		t.Fatalf("ReAuth error = %v, want the token outside the refresh window", err)
+	}
+
+	svc.jwt.refreshWindow = svc.jwt.ttl
+	reauth, err := svc.ReAuth(ctx)
+	if err != nil {
+		t.Fatalf("ReAuth: %v", err)
+	}
+	if reauth.ExpiresAt.Before(res.ExpiresAt) {
+		t.Errorf("ExpiresAt = %v, want after %v", reauth.ExpiresAt, res.ExpiresAt)
+	}
+}
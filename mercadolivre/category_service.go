@@ -2,13 +2,16 @@ package mercadolivre
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
 type CategoryRequest struct {
-	Name string `validate:"required,not_blank,should_be_unique"`
+	Name     string `validate:"required,not_blank,should_be_unique=categories.name"`
+	ParentID string `json:"parent_id" validate:"omitempty,uuid,should_exist=categories.id"`
 }
 
 type CategoryResponse struct {
@@ -16,32 +19,200 @@ type CategoryResponse struct {
 	Name string
 }
 
+type CategoryTreeResponse struct {
+	ID       string
+	Name     string
+	Children []*CategoryTreeResponse `json:",omitempty"`
+}
+
 // Validate validates CategoryRequest.
-func (c CategoryRequest) Validate() error {
-	return Validate(c)
+func (c CategoryRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, c)
 }
 
 // Category represents a single Category.
 // ID should be globally unique.
 type Category struct {
-	ID   string
-	Name string
+	ID       string
+	Name     string
+	ParentID sql.NullString `db:"parent_id"`
 }
 
 // CategoryPost creates category.
 func (s *service) CategoryPost(ctx context.Context, category CategoryRequest) (string, error) {
-	stmt, err := s.db.Prepare("INSERT INTO categories (id, name) VALUES ($1, $2)")
 	msgError := "service.category_post"
-	if err != nil {
-		return "", errors.Wrap(err, msgError)
-	}
 	id := uuid.New().String()
-	_, err = stmt.Exec(
-		id,
-		category.Name,
-	)
+	err := s.repo.CreateCategory(ctx, Category{
+		ID:   id,
+		Name: category.Name,
+		ParentID: sql.NullString{
+			String: category.ParentID,
+			Valid:  category.ParentID != "",
+		},
+	})
 	if err != nil {
 		return "", errors.Wrap(err, msgError)
 	}
 	return id, nil
 }
+
+// CategoryPathGet returns the Category's breadcrumb, from the root to the Category.
+func (s *service) CategoryPathGet(ctx context.Context, categoryID string) ([]CategoryResponse, error) {
+	msgError := "service.category_path_get"
+	errNotFound := fmt.Errorf("%w: category %s", ErrNotFound, categoryID)
+	if _, err := uuid.Parse(categoryID); err != nil {
+		return nil, errors.Wrap(errNotFound, msgError)
+	}
+	path, err := s.categoryPath(ctx, categoryID)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+	if len(path) == 0 {
+		return nil, errors.Wrap(errNotFound, msgError)
+	}
+	return path, nil
+}
+
+// categoryPath returns the Category's ancestors followed by the Category.
+func (s *service) categoryPath(ctx context.Context, categoryID string) ([]CategoryResponse, error) {
+	categories, err := s.repo.CategoryPath(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	path := make([]CategoryResponse, len(categories))
+	for i, category := range categories {
+		path[i] = CategoryResponse{
+			ID:   category.ID,
+			Name: category.Name,
+		}
+	}
+	return path, nil
+}
+
+// CategoryTreeGet returns the Category's subtree.
+func (s *service) CategoryTreeGet(ctx context.Context, categoryID string) (*CategoryTreeResponse, error) {
+	msgError := "service.category_tree_get"
+	errNotFound := fmt.Errorf("%w: category %s", ErrNotFound, categoryID)
+	if _, err := uuid.Parse(categoryID); err != nil {
+		return nil, errors.Wrap(errNotFound, msgError)
+	}
+
+	categories, err := s.repo.CategorySubtree(ctx, categoryID)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	nodes := make(map[string]*CategoryTreeResponse, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryTreeResponse{
+			ID:   category.ID,
+			Name: category.Name,
+		}
+	}
+	root, ok := nodes[categoryID]
+	if !ok {
+		return nil, errors.Wrap(errNotFound, msgError)
+	}
+	for _, category := range categories {
+		if category.ID == categoryID {
+			continue
+		}
+		if parent, ok := nodes[category.ParentID.String]; ok {
+			parent.Children = append(parent.Children, nodes[category.ID])
+		}
+	}
+	return root, nil
+}
+
+type CategoriesResponse []CategoryListResponse
+
+type CategoryListResponse struct {
+	ID            string
+	Name          string
+	ParentID      string `json:"parent_id,omitempty" db:"parent_id"`
+	ProductsCount int    `json:"products_count" db:"products_count"`
+}
+
+// CategoriesGet lists the Categories with the number of Products in each one.
+func (s *service) CategoriesGet(ctx context.Context) (CategoriesResponse, error) {
+	msgError := "service.categories_get"
+	categories, err := s.repo.Categories(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+	return categories, nil
+}
+
+type CategoryPatchRequest struct {
+	ID       string  `json:"-" validate:"required,uuid"`
+	Name     *string `validate:"omitempty,not_blank,should_be_unique=categories.name ID"`
+	ParentID *string `json:"parent_id" validate:"omitempty,uuid,nefield=ID,should_exist=categories.id"`
+}
+
+// Validate validates CategoryPatchRequest.
+func (c CategoryPatchRequest) Validate(ctx context.Context) error {
+	// omitempty only skips a nil ParentID: an empty one, moving the Category
+	// to the root, is not a uuid nor an existing Category to check.
+	if c.ParentID != nil && *c.ParentID == "" {
+		c.ParentID = nil
+	}
+	return Validate(ctx, c)
+}
+
+// CategoryPatch renames the Category or moves it under another parent.
+// An empty parent_id moves it to the root.
+func (s *service) CategoryPatch(ctx context.Context, category CategoryPatchRequest) error {
+	msgError := "service.category_patch"
+	err := s.repo.UpdateCategory(ctx, CategoryUpdate{
+		ID:       category.ID,
+		Name:     category.Name,
+		ParentID: category.ParentID,
+	})
+	if err != nil {
+		if errors.Is(err, ErrCreatesCycle) {
+			return ValidationErrorsResponse{
+				&ValidationErrorResponse{
+					FailedField: "categorypatchrequest.parent_id",
+					Condition:   ErrCreatesCycle.Error(),
+					ActualValue: *category.ParentID,
+				},
+			}
+		}
+		return errors.Wrap(err, msgError)
+	}
+	return nil
+}
+
+type CategoryDeleteRequest struct {
+	ID string `validate:"required,uuid"`
+	// ReassignTo moves the Category's Products to another Category before deleting it.
+	ReassignTo string `json:"reassign_to" validate:"omitempty,uuid,nefield=ID,should_exist=categories.id"`
+}
+
+// Validate validates CategoryDeleteRequest.
+func (c CategoryDeleteRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, c)
+}
+
+// CategoryDelete deletes the Category. It is refused while Products
+// reference the Category, unless they are reassigned to another one,
+// and while the Category has children.
+func (s *service) CategoryDelete(ctx context.Context, category CategoryDeleteRequest) error {
+	msgError := "service.category_delete"
+	err := s.repo.DeleteCategory(ctx, category.ID, category.ReassignTo)
+	if err != nil {
+		for _, condition := range []error{ErrHasChildren, ErrInUse} {
+			if errors.Is(err, condition) {
+				return ValidationErrorsResponse{
+					&ValidationErrorResponse{
+						FailedField: "categorydeleterequest.id",
+						Condition:   condition.Error(),
+						ActualValue: category.ID,
+					},
+				}
+			}
+		}
+		return errors.Wrap(err, msgError)
+	}
+	return nil
+}
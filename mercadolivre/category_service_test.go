@@ -0,0 +1,74 @@
+package mercadolivre
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCategoryDeleteIgnoresDeletedProducts(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := contextWithUser(context.Background(), createTestUser(t, svc, "owner@example.com", "secret123"))
+	categoryID, err := svc.CategoryPost(ctx, CategoryRequest{Name: "Books"})
+	if err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+	price, amount := float32(10), int16(1)
+	productID, err := svc.ProductPost(ctx, ProductRequest{
+		Name:       "Book",
+		Price:      &price,
+		Amount:     &amount,
+		Features:   []Feature{{Type: "a", Name: "a"}, {Type: "b", Name: "b"}},
+		CategoryID: categoryID,
+	})
+	if err != nil {
+		t.Fatalf("ProductPost: %v", err)
+	}
+
+	err = svc.CategoryDelete(ctx, CategoryDeleteRequest{ID: categoryID})
+	if !hasFailedField(err, "categorydeleterequest.id") {
+		t.Fatalf("CategoryDelete error = %v, want the category in use", err)
+	}
+
+	if err := svc.ProductDelete(ctx, productID); err != nil {
+		t.Fatalf("ProductDelete: %v", err)
+	}
+	categories, err := svc.CategoriesGet(ctx)
+	if err != nil {
+		t.Fatalf("CategoriesGet: %v", err)
+	}
+	if len(categories) != 1 || categories[0].ProductsCount != 0 {
+		t.Fatalf("CategoriesGet = %+v, want Books without products", categories)
+	}
+	if err := svc.CategoryDelete(ctx, CategoryDeleteRequest{ID: categoryID}); err != nil {
+		t.Fatalf("CategoryDelete: %v", err)
+	}
+}
+
+func TestCategoryPatchMovesToTheRoot(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := contextWithUser(context.Background(), createTestUser(t, svc, "owner@example.com", "secret123"))
+	booksID, err := svc.CategoryPost(ctx, CategoryRequest{Name: "Books"})
+	if err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+	novelsID, err := svc.CategoryPost(ctx, CategoryRequest{Name: "Novels", ParentID: booksID})
+	if err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+
+	root := ""
+	req := CategoryPatchRequest{ID: novelsID, ParentID: &root}
+	if err := req.Validate(ctx); err != nil {
+		t.Fatalf("Validate error = %v, want nil", err)
+	}
+	if err := svc.CategoryPatch(ctx, req); err != nil {
+		t.Fatalf("CategoryPatch: %v", err)
+	}
+	path, err := svc.CategoryPathGet(ctx, novelsID)
+	if err != nil {
+		t.Fatalf("CategoryPathGet: %v", err)
+	}
+	if len(path) != 1 || path[0].ID != novelsID {
+		t.Fatalf("CategoryPathGet = %+v, want Novels at the root", path)
+	}
+}
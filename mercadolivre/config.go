@@ -1,6 +1,10 @@
 package mercadolivre
 
-import "database/sql"
+import (
+	"database/sql"
+	"net/http"
+	"time"
+)
 
 // Config is used to configure the server
 type Config struct {
@@ -8,8 +12,102 @@ type Config struct {
 	Host string
 	// Port defines the network port we bind to.
 	Port int
+	// Server defines the HTTP server's timeouts.
+	Server ServerConfig
+	// BaseURL defines the public URL the server is reached at.
+	BaseURL string
 	// DB contains the DB we connect to.
 	DB *sql.DB
 	// DriverName defines the database driver name.
 	DriverName string
+	// Repository defines where the users, categories, products, purchases,
+	// opinions and questions are stored. If nil, they are stored into DB.
+	Repository Repository
+	// JWT defines how the tokens are signed.
+	JWT JWTConfig
+	// Cookies defines the attributes of the cookies holding the tokens.
+	Cookies CookieConfig
+	// GatewaySecrets defines the secret each payment gateway signs its
+	// callbacks with. The callbacks of a gateway without a secret are refused.
+	GatewaySecrets map[Gateway]string
+	// Storage defines where the uploaded files are stored.
+	Storage Storage
+	// Mailer defines how the e-mail notifications are sent.
+	Mailer Mailer
+	// PurchaseConfirmedHandlers defines who is notified once a purchase is paid.
+	PurchaseConfirmedHandlers []PurchaseConfirmedHandler
+	// EventAttempts defines how many times a handler is called before giving up.
+	EventAttempts int
+	// EventBackoff defines the delay before the first retry of a handler.
+	EventBackoff time.Duration
+	// Metrics defines where the requests and the business events are
+	// recorded. If nil, they are discarded.
+	Metrics *Metrics
+	// MetricsServer defines the separate listener serving Metrics on /metrics.
+	MetricsServer MetricsServerConfig
+	// Timeouts defines how long the endpoints may take.
+	Timeouts TimeoutConfig
+	// StandIns mounts local stand-ins for the invoice and the seller-ranking
+	// systems under /stand-ins.
+	StandIns bool
+}
+
+// ServerConfig is used to configure the HTTP server's timeouts.
+// The zero timeouts take the defaults.
+type ServerConfig struct {
+	// ReadTimeout defines how long reading a request, including its body, may take.
+	ReadTimeout time.Duration
+	// ReadHeaderTimeout defines how long reading a request's headers may take.
+	ReadHeaderTimeout time.Duration
+	// WriteTimeout defines how long handling a request may take once its
+	// headers are read. It should exceed the endpoints' deadlines.
+	WriteTimeout time.Duration
+	// IdleTimeout defines how long a keep-alive connection waits for the
+	// next request.
+	IdleTimeout time.Duration
+	// ShutdownTimeout defines how long the in-flight requests are given to
+	// complete once the server is shutting down.
+	ShutdownTimeout time.Duration
+}
+
+// MetricsServerConfig is used to configure the listener serving the metrics.
+type MetricsServerConfig struct {
+	// Host defines the network addresses the listener binds to.
+	Host string
+	// Port defines the network port the listener binds to. If zero, the
+	// metrics are not served.
+	Port int
+}
+
+// CookieConfig is used to configure the cookies holding the tokens.
+type CookieConfig struct {
+	// Domain defines the cookies' Domain attribute.
+	Domain string
+	// HTTPOnly hides the token cookie from scripts.
+	// The refresh token cookie is always HttpOnly.
+	HTTPOnly bool
+	// Secure sends the cookies only over HTTPS.
+	Secure bool
+	// SameSite defines the cookies' SameSite attribute.
+	SameSite http.SameSite
+}
+
+// TimeoutConfig is used to configure the endpoints' deadlines.
+// The queries still running once a deadline is exceeded are canceled and
+// the request fails with 504 Gateway Timeout.
+type TimeoutConfig struct {
+	// Default defines the deadline of the endpoints missing from Endpoints.
+	// If zero, they have no deadline.
+	Default time.Duration
+	// Endpoints defines the deadlines by endpoint, named after the Service's
+	// methods, such as "ProductSearch". A zero deadline disables the Default.
+	Endpoints map[string]time.Duration
+}
+
+// timeout returns the deadline of the endpoint.
+func (cfg TimeoutConfig) timeout(endpoint string) time.Duration {
+	if d, ok := cfg.Endpoints[endpoint]; ok {
+		return d
+	}
+	return cfg.Default
 }
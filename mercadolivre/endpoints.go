@@ -3,31 +3,72 @@ package mercadolivre
 import (
 	"context"
 
-	"github.com/dgrijalva/jwt-go"
-	jwtKit "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
 )
 
 // Endpoints collects all of the endpoints.
 type Endpoints struct {
-	AuthEndpoint         endpoint.Endpoint
-	CategoryPostEndpoint endpoint.Endpoint
-	ProductPostEndpoint  endpoint.Endpoint
-	ReAuthEndpoint       endpoint.Endpoint
-	UserPostEndpoint     endpoint.Endpoint
+	AuthEndpoint              endpoint.Endpoint
+	CategoriesGetEndpoint     endpoint.Endpoint
+	CategoryDeleteEndpoint    endpoint.Endpoint
+	CategoryPatchEndpoint     endpoint.Endpoint
+	CategoryPathGetEndpoint   endpoint.Endpoint
+	CategoryPostEndpoint      endpoint.Endpoint
+	CategoryTreeGetEndpoint   endpoint.Endpoint
+	JWKSEndpoint              endpoint.Endpoint
+	LogoutEndpoint            endpoint.Endpoint
+	OpinionPostEndpoint       endpoint.Endpoint
+	PaymentPostEndpoint       endpoint.Endpoint
+	ProductDeleteEndpoint     endpoint.Endpoint
+	ProductGetEndpoint        endpoint.Endpoint
+	ProductImagesPostEndpoint endpoint.Endpoint
+	ProductPatchEndpoint      endpoint.Endpoint
+	ProductPostEndpoint       endpoint.Endpoint
+	ProductPutEndpoint        endpoint.Endpoint
+	ProductSearchEndpoint     endpoint.Endpoint
+	ProductsGetEndpoint       endpoint.Endpoint
+	PurchasePostEndpoint      endpoint.Endpoint
+	QuestionPostEndpoint      endpoint.Endpoint
+	ReAuthEndpoint            endpoint.Endpoint
+	RefreshEndpoint           endpoint.Endpoint
+	UserPostEndpoint          endpoint.Endpoint
 }
 
-// MakeServerEndpoints returns an Endpoints struct.
-func MakeServerEndpoints(svc Service) Endpoints {
-	kf := func(token *jwt.Token) (interface{}, error) { return []byte("myJWTSecretKey"), nil }
-	AuthMdlwr := jwtKit.NewParser(kf, jwt.SigningMethodHS256, jwtKit.StandardClaimsFactory)
-
+// MakeServerEndpoints returns an Endpoints struct. Each endpoint is
+// recorded into metrics and given the deadline Timeouts define for it.
+// The payment callbacks are authenticated by CallbackAuthMdlwr.
+func MakeServerEndpoints(svc Service, AuthMdlwr, CallbackAuthMdlwr endpoint.Middleware, timeouts TimeoutConfig, metrics *Metrics) Endpoints {
+	mdlwr := func(name string) endpoint.Middleware {
+		return endpoint.Chain(
+			InstrumentingMdlwr(metrics, name),
+			TimeoutMdlwr(timeouts.timeout(name)),
+		)
+	}
 	return Endpoints{
-		AuthEndpoint:         ValidationMdlwr()(MakeAuthEndpoint(svc)),
-		CategoryPostEndpoint: AuthMdlwr(ValidationMdlwr()(MakeCategoryPostEndpoint(svc))),
-		ProductPostEndpoint:  AuthMdlwr(ValidationMdlwr()(MakeProductPostEndpoint(svc))),
-		ReAuthEndpoint:       (MakeReAuthEndpoint(svc)),
-		UserPostEndpoint:     ValidationMdlwr()(MakeUserPostEndpoint(svc)),
+		AuthEndpoint:              mdlwr("Auth")(ValidationMdlwr()(MakeAuthEndpoint(svc))),
+		CategoriesGetEndpoint:     mdlwr("CategoriesGet")(MakeCategoriesGetEndpoint(svc)),
+		CategoryDeleteEndpoint:    mdlwr("CategoryDelete")(AuthMdlwr(ValidationMdlwr()(MakeCategoryDeleteEndpoint(svc)))),
+		CategoryPatchEndpoint:     mdlwr("CategoryPatch")(AuthMdlwr(ValidationMdlwr()(MakeCategoryPatchEndpoint(svc)))),
+		CategoryPathGetEndpoint:   mdlwr("CategoryPathGet")(MakeCategoryPathGetEndpoint(svc)),
+		CategoryPostEndpoint:      mdlwr("CategoryPost")(AuthMdlwr(ValidationMdlwr()(MakeCategoryPostEndpoint(svc)))),
+		CategoryTreeGetEndpoint:   mdlwr("CategoryTreeGet")(MakeCategoryTreeGetEndpoint(svc)),
+		JWKSEndpoint:              mdlwr("JWKS")(MakeJWKSEndpoint(svc)),
+		LogoutEndpoint:            mdlwr("Logout")(ValidationMdlwr()(MakeLogoutEndpoint(svc))),
+		OpinionPostEndpoint:       mdlwr("OpinionPost")(AuthMdlwr(ValidationMdlwr()(MakeOpinionPostEndpoint(svc)))),
+		PaymentPostEndpoint:       mdlwr("PaymentPost")(CallbackAuthMdlwr(ValidationMdlwr()(MakePaymentPostEndpoint(svc)))),
+		ProductDeleteEndpoint:     mdlwr("ProductDelete")(AuthMdlwr(MakeProductDeleteEndpoint(svc))),
+		ProductGetEndpoint:        mdlwr("ProductGet")(MakeProductGetEndpoint(svc)),
+		ProductImagesPostEndpoint: mdlwr("ProductImagesPost")(AuthMdlwr(ValidationMdlwr()(MakeProductImagesPostEndpoint(svc)))),
+		ProductPatchEndpoint:      mdlwr("ProductPatch")(AuthMdlwr(ValidationMdlwr()(MakeProductPatchEndpoint(svc)))),
+		ProductPostEndpoint:       mdlwr("ProductPost")(AuthMdlwr(ValidationMdlwr()(MakeProductPostEndpoint(svc)))),
+		ProductPutEndpoint:        mdlwr("ProductPut")(AuthMdlwr(ValidationMdlwr()(MakeProductPutEndpoint(svc)))),
+		ProductSearchEndpoint:     mdlwr("ProductSearch")(ValidationMdlwr()(MakeProductSearchEndpoint(svc))),
+		ProductsGetEndpoint:       mdlwr("ProductsGet")(ValidationMdlwr()(MakeProductsGetEndpoint(svc))),
+		PurchasePostEndpoint:      mdlwr("PurchasePost")(AuthMdlwr(ValidationMdlwr()(MakePurchasePostEndpoint(svc)))),
+		QuestionPostEndpoint:      mdlwr("QuestionPost")(AuthMdlwr(ValidationMdlwr()(MakeQuestionPostEndpoint(svc)))),
+		ReAuthEndpoint:            mdlwr("ReAuth")(MakeReAuthEndpoint(svc)),
+		RefreshEndpoint:           mdlwr("Refresh")(ValidationMdlwr()(MakeRefreshEndpoint(svc))),
+		UserPostEndpoint:          mdlwr("UserPost")(ValidationMdlwr()(MakeUserPostEndpoint(svc))),
 	}
 }
 
@@ -47,6 +88,51 @@ func MakeAuthEndpoint(svc Service) endpoint.Endpoint {
 	}
 }
 
+// MakeCategoriesGetEndpoint returns an endpoint via the passed service.
+func MakeCategoriesGetEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		res, err := svc.CategoriesGet(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeCategoryDeleteEndpoint returns an endpoint via the passed service.
+func MakeCategoryDeleteEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(CategoryDeleteRequest)
+		if err := svc.CategoryDelete(ctx, req); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+// MakeCategoryPatchEndpoint returns an endpoint via the passed service.
+func MakeCategoryPatchEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(CategoryPatchRequest)
+		if err := svc.CategoryPatch(ctx, req); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+// MakeCategoryPathGetEndpoint returns an endpoint via the passed service.
+func MakeCategoryPathGetEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		id := request.(string)
+		res, err := svc.CategoryPathGet(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
 // MakeCategoryPostEndpoint returns an endpoint via the passed service.
 func MakeCategoryPostEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -61,6 +147,54 @@ func MakeCategoryPostEndpoint(svc Service) endpoint.Endpoint {
 	}
 }
 
+// MakeCategoryTreeGetEndpoint returns an endpoint via the passed service.
+func MakeCategoryTreeGetEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		id := request.(string)
+		res, err := svc.CategoryTreeGet(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeJWKSEndpoint returns an endpoint via the passed service.
+func MakeJWKSEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		res, err := svc.JWKS(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeLogoutEndpoint returns an endpoint via the passed service.
+func MakeLogoutEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(LogoutRequest)
+		if err := svc.Logout(ctx, req); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+// MakeOpinionPostEndpoint returns an endpoint via the passed service.
+func MakeOpinionPostEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(OpinionRequest)
+		id, err := svc.OpinionPost(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return postResponse{
+			ID: id,
+		}, nil
+	}
+}
+
 // MakeReAuthEndpoint returns an endpoint via the passed service.
 func MakeReAuthEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -72,6 +206,64 @@ func MakeReAuthEndpoint(svc Service) endpoint.Endpoint {
 	}
 }
 
+// MakePaymentPostEndpoint returns an endpoint via the passed service.
+func MakePaymentPostEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(PaymentRequest)
+		res, err := svc.PaymentPost(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeRefreshEndpoint returns an endpoint via the passed service.
+func MakeRefreshEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(RefreshRequest)
+		res, err := svc.Refresh(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeProductDeleteEndpoint returns an endpoint via the passed service.
+func MakeProductDeleteEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		id := request.(string)
+		if err := svc.ProductDelete(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+// MakeProductGetEndpoint returns an endpoint via the passed service.
+func MakeProductGetEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		id := request.(string)
+		res, err := svc.ProductGet(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeProductPatchEndpoint returns an endpoint via the passed service.
+func MakeProductPatchEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ProductPatchRequest)
+		if err := svc.ProductPatch(ctx, req); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
 // MakeProductPostEndpoint returns an endpoint via the passed service.
 func MakeProductPostEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -86,6 +278,79 @@ func MakeProductPostEndpoint(svc Service) endpoint.Endpoint {
 	}
 }
 
+// MakeProductPutEndpoint returns an endpoint via the passed service.
+func MakeProductPutEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ProductPutRequest)
+		if err := svc.ProductPut(ctx, req); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+}
+
+// MakeProductSearchEndpoint returns an endpoint via the passed service.
+func MakeProductSearchEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ProductSearchRequest)
+		res, err := svc.ProductSearch(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeProductsGetEndpoint returns an endpoint via the passed service.
+func MakeProductsGetEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ProductsRequest)
+		res, err := svc.ProductsGet(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeProductImagesPostEndpoint returns an endpoint via the passed service.
+func MakeProductImagesPostEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(ProductImagesRequest)
+		res, err := svc.ProductImagesPost(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakePurchasePostEndpoint returns an endpoint via the passed service.
+func MakePurchasePostEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(PurchaseRequest)
+		res, err := svc.PurchasePost(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
+
+// MakeQuestionPostEndpoint returns an endpoint via the passed service.
+func MakeQuestionPostEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(QuestionRequest)
+		id, err := svc.QuestionPost(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return postResponse{
+			ID: id,
+		}, nil
+	}
+}
+
 // MakeUserPostEndpoint returns an endpoint via the passed service.
 func MakeUserPostEndpoint(svc Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
@@ -1,18 +1,170 @@
 package mercadolivre
 
 import (
+	"context"
 	"errors"
 	"net/http"
+
+	jwtKit "github.com/go-kit/kit/auth/jwt"
+	"github.com/lib/pq"
 )
 
 var (
-	ErrAlreadyExists    = errors.New("already exists")
-	ErrAuthFailed       = errors.New("authentication failed")
-	ErrInternalServer   = errors.New(http.StatusText(http.StatusInternalServerError))
-	ErrIsNotValid       = errors.New("is not valid")
-	ErrMissingToken     = errors.New("missing token")
-	ErrNotFound         = errors.New("not found")
-	ErrShouldBeFuture   = errors.New("should be in the future")
-	ErrShouldBeUnique   = errors.New("should be unique")
-	ErrValidationFailed = errors.New("validation failed")
+	ErrAlreadyExists     = errors.New("already exists")
+	ErrAlreadyPaid       = errors.New("already paid")
+	ErrAuthFailed        = errors.New("authentication failed")
+	ErrBodyTooLarge      = errors.New("body too large")
+	ErrCreatesCycle      = errors.New("should not create a cycle")
+	ErrForbidden         = errors.New(http.StatusText(http.StatusForbidden))
+	ErrHasChildren       = errors.New("should not have children")
+	ErrInUse             = errors.New("is in use")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInternalServer    = errors.New(http.StatusText(http.StatusInternalServerError))
+	ErrIsNotValid        = errors.New("is not valid")
+	ErrMalformedBody     = errors.New("malformed body")
+	ErrMissingToken      = errors.New("missing token")
+	ErrNotFound          = errors.New("not found")
+	ErrShouldBeFuture    = errors.New("should be in the future")
+	ErrShouldBeUnique    = errors.New("should be unique")
+	ErrValidationFailed  = errors.New("validation failed")
+)
+
+// ErrorCode is a stable machine-readable error code. Clients should branch
+// on it instead of on the error messages.
+type ErrorCode string
+
+const (
+	CodeAlreadyExists     ErrorCode = "already_exists"
+	CodeAlreadyPaid       ErrorCode = "already_paid"
+	CodeAuthFailed        ErrorCode = "auth_failed"
+	CodeBodyTooLarge      ErrorCode = "body_too_large"
+	CodeCanceled          ErrorCode = "canceled"
+	CodeCreatesCycle      ErrorCode = "creates_cycle"
+	CodeDeadlineExceeded  ErrorCode = "deadline_exceeded"
+	CodeForbidden         ErrorCode = "forbidden"
+	CodeHasChildren       ErrorCode = "has_children"
+	CodeInUse             ErrorCode = "in_use"
+	CodeInsufficientStock ErrorCode = "insufficient_stock"
+	CodeInternalServer    ErrorCode = "internal_server_error"
+	CodeIsNotValid        ErrorCode = "is_not_valid"
+	CodeMalformedBody     ErrorCode = "malformed_body"
+	CodeMissingToken      ErrorCode = "missing_token"
+	CodeNotFound          ErrorCode = "not_found"
+	CodeShouldBeFuture    ErrorCode = "should_be_future"
+	CodeShouldBeUnique    ErrorCode = "should_be_unique"
+	CodeValidationFailed  ErrorCode = "validation_failed"
+)
+
+// statusClientClosedRequest is the non-standard status, borrowed from nginx,
+// of the requests the client went away from before they were answered.
+const statusClientClosedRequest = 499
+
+// catalogueEntry is the code and the HTTP status of an error.
+type catalogueEntry struct {
+	Err    error
+	Code   ErrorCode
+	Status int
+}
+
+// errorCatalogue maps each sentinel error to its code and HTTP status.
+var errorCatalogue = []catalogueEntry{
+	{ErrAlreadyExists, CodeAlreadyExists, http.StatusConflict},
+	{ErrAlreadyPaid, CodeAlreadyPaid, http.StatusConflict},
+	{ErrAuthFailed, CodeAuthFailed, http.StatusUnauthorized},
+	{ErrBodyTooLarge, CodeBodyTooLarge, http.StatusRequestEntityTooLarge},
+	{context.Canceled, CodeCanceled, statusClientClosedRequest},
+	{ErrCreatesCycle, CodeCreatesCycle, http.StatusConflict},
+	{context.DeadlineExceeded, CodeDeadlineExceeded, http.StatusGatewayTimeout},
+	{ErrForbidden, CodeForbidden, http.StatusForbidden},
+	{ErrHasChildren, CodeHasChildren, http.StatusConflict},
+	{ErrInUse, CodeInUse, http.StatusConflict},
+	{ErrInsufficientStock, CodeInsufficientStock, http.StatusConflict},
+	{ErrInternalServer, CodeInternalServer, http.StatusInternalServerError},
+	{ErrIsNotValid, CodeIsNotValid, http.StatusBadRequest},
+	{ErrMalformedBody, CodeMalformedBody, http.StatusBadRequest},
+	{ErrMissingToken, CodeMissingToken, http.StatusUnauthorized},
+	{ErrNotFound, CodeNotFound, http.StatusNotFound},
+	{ErrShouldBeFuture, CodeShouldBeFuture, http.StatusBadRequest},
+	{ErrShouldBeUnique, CodeShouldBeUnique, http.StatusConflict},
+	{ErrValidationFailed, CodeValidationFailed, http.StatusBadRequest},
+}
+
+// authErrors are the go-kit JWT errors, reported as ErrAuthFailed.
+var authErrors = []error{
+	jwtKit.ErrTokenContextMissing,
+	jwtKit.ErrUnexpectedSigningMethod,
+	jwtKit.ErrTokenMalformed,
+	jwtKit.ErrTokenExpired,
+	jwtKit.ErrTokenNotActive,
+	jwtKit.ErrTokenInvalid,
+}
+
+const (
+	// uniqueViolation is the PostgreSQL error code of a unique constraint violation.
+	uniqueViolation = "23505"
+	// queryCanceled is the PostgreSQL error code of a query canceled, as
+	// lib/pq does once the query's context is done. TimeoutMdlwr reports
+	// the context's error instead.
+	queryCanceled = "57014"
 )
+
+// catalogueEntryFrom returns the catalogue entry of err.
+// Validation errors whose failures all share a catalogued condition take
+// that condition's entry, and unknown errors are internal server errors.
+func catalogueEntryFrom(err error) catalogueEntry {
+	if e, ok := err.(ValidationErrorsResponse); ok {
+		return validationEntryFrom(e)
+	}
+	for _, authErr := range authErrors {
+		if errors.Is(err, authErr) {
+			return catalogueEntryOf(ErrAuthFailed)
+		}
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return catalogueEntryOf(ErrAlreadyExists)
+	}
+	for _, entry := range errorCatalogue {
+		if errors.Is(err, entry.Err) {
+			return entry
+		}
+	}
+	return catalogueEntryOf(ErrInternalServer)
+}
+
+func validationEntryFrom(errs ValidationErrorsResponse) catalogueEntry {
+	validationFailed := catalogueEntryOf(ErrValidationFailed)
+	var found *catalogueEntry
+	for _, e := range errs {
+		entry, ok := catalogueEntryByCondition(e.Condition)
+		if !ok || (found != nil && found.Code != entry.Code) {
+			return validationFailed
+		}
+		found = &entry
+	}
+	if found == nil {
+		return validationFailed
+	}
+	return *found
+}
+
+func catalogueEntryByCondition(condition string) (catalogueEntry, bool) {
+	for _, entry := range errorCatalogue {
+		if entry.Err.Error() == condition || string(entry.Code) == condition {
+			return entry, true
+		}
+	}
+	return catalogueEntry{}, false
+}
+
+// catalogueEntryOf returns the catalogue entry of the sentinel err. Every
+// sentinel is catalogued, as errors_test.go checks; the others would be
+// internal server errors.
+func catalogueEntryOf(err error) catalogueEntry {
+	for _, entry := range errorCatalogue {
+		if entry.Err == err {
+			return entry
+		}
+	}
+	return catalogueEntry{err, CodeInternalServer, http.StatusInternalServerError}
+}
@@ -0,0 +1,92 @@
+package mercadolivre
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// sentinels are the errors passed to catalogueEntryOf and those a
+// ValidationErrorResponse's Condition may hold.
+var sentinels = []error{
+	ErrAlreadyExists,
+	ErrAlreadyPaid,
+	ErrAuthFailed,
+	ErrBodyTooLarge,
+	context.Canceled,
+	ErrCreatesCycle,
+	context.DeadlineExceeded,
+	ErrForbidden,
+	ErrHasChildren,
+	ErrInUse,
+	ErrInsufficientStock,
+	ErrInternalServer,
+	ErrIsNotValid,
+	ErrMalformedBody,
+	ErrMissingToken,
+	ErrNotFound,
+	ErrShouldBeFuture,
+	ErrShouldBeUnique,
+	ErrValidationFailed,
+}
+
+func TestErrorCatalogue(t *testing.T) {
+	catalogued := map[error]bool{}
+	codes := map[ErrorCode]bool{}
+	for _, entry := range errorCatalogue {
+		if catalogued[entry.Err] {
+			t.Errorf("%v is catalogued twice", entry.Err)
+		}
+		if codes[entry.Code] {
+			t.Errorf("code %s is used twice", entry.Code)
+		}
+		if http.StatusText(entry.Status) == "" && entry.Status != statusClientClosedRequest {
+			t.Errorf("%v has the unknown status %d", entry.Err, entry.Status)
+		}
+		catalogued[entry.Err] = true
+		codes[entry.Code] = true
+	}
+	for _, err := range sentinels {
+		if !catalogued[err] {
+			t.Errorf("%v is not catalogued", err)
+		}
+	}
+}
+
+func TestTimeoutMdlwrReportsCanceledQueries(t *testing.T) {
+	// query fails as lib/pq does once the context is done.
+	query := func(ctx context.Context, request interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, &pq.Error{Code: queryCanceled, Message: "canceling statement due to user request"}
+	}
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		status  int
+	}{
+		{"deadline exceeded", time.Millisecond, http.StatusGatewayTimeout},
+		{"client gone", 0, statusClientClosedRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.timeout == 0 {
+				cancel()
+			} else {
+				defer cancel()
+			}
+			_, err := TimeoutMdlwr(tt.timeout)(query)(ctx, nil)
+			if entry := catalogueEntryFrom(err); entry.Status != tt.status {
+				t.Fatalf("status = %d, want %d: %v", entry.Status, tt.status, err)
+			}
+		})
+	}
+
+	err := &pq.Error{Code: queryCanceled}
+	if entry := catalogueEntryFrom(err); entry.Status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d for a query canceled by the server", entry.Status, http.StatusInternalServerError)
+	}
+}
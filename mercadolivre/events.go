@@ -0,0 +1,130 @@
+package mercadolivre
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultEventAttempts is the number of times a handler is called before giving up.
+	defaultEventAttempts = 5
+	// defaultEventBackoff is the delay before the first retry. It doubles on every retry.
+	defaultEventBackoff = time.Second
+)
+
+// PurchaseConfirmed is fired once a Purchase is paid.
+type PurchaseConfirmed struct {
+	PurchaseID string
+	ProductID  string
+	BuyerID    string
+	SellerID   string
+}
+
+// ErrPermanent marks the handlers' errors which retrying would not fix, such
+// as a request the notified system refused. The handler is not called again.
+var ErrPermanent = errors.New("permanent failure")
+
+// PurchaseConfirmedHandler handles the PurchaseConfirmed events.
+type PurchaseConfirmedHandler interface {
+	HandlePurchaseConfirmed(ctx context.Context, event PurchaseConfirmed) error
+}
+
+// events runs the event handlers in the background until it is closed.
+type events struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	closed  bool
+	running sync.WaitGroup
+}
+
+func newEvents() *events {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &events{ctx: ctx, cancel: cancel}
+}
+
+// goHandle runs handle in the background, unless e is closed.
+func (e *events) goHandle(handle func(ctx context.Context)) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return false
+	}
+	e.running.Add(1)
+	go func() {
+		defer e.running.Done()
+		handle(e.ctx)
+	}()
+	return true
+}
+
+// close stops running new handlers and waits for the running ones to
+// complete. Once ctx is done, they are canceled.
+func (e *events) close(ctx context.Context) error {
+	e.mu.Lock()
+	e.closed = true
+	e.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		e.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		e.cancel()
+		return nil
+	case <-ctx.Done():
+		e.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// firePurchaseConfirmed passes event to every PurchaseConfirmedHandler.
+// The handlers run in the background, so the caller is never blocked.
+func (s *service) firePurchaseConfirmed(_ context.Context, event PurchaseConfirmed) {
+	for _, handler := range s.purchaseConfirmedHandlers {
+		handler := handler
+		if !s.events.goHandle(func(ctx context.Context) {
+			s.handlePurchaseConfirmed(ctx, handler, event)
+		}) {
+			s.logger.Errorf("purchase %s confirmed: %T not called, the service is shut down", event.PurchaseID, handler)
+		}
+	}
+}
+
+// handlePurchaseConfirmed calls handler until it succeeds, fails with
+// ErrPermanent, the attempts run out or ctx is done.
+func (s *service) handlePurchaseConfirmed(ctx context.Context, handler PurchaseConfirmedHandler, event PurchaseConfirmed) {
+	backoff := s.eventBackoff
+	for attempt := 1; ; attempt++ {
+		err := handler.HandlePurchaseConfirmed(ctx, event)
+		if err == nil {
+			return
+		}
+		if attempt >= s.eventAttempts || ctx.Err() != nil || errors.Is(err, ErrPermanent) {
+			s.logger.Errorf("purchase %s confirmed: %T gave up after %d attempts: %v", event.PurchaseID, handler, attempt, err)
+			return
+		}
+		s.logger.Warnf("purchase %s confirmed: %T attempt %d failed, retrying in %s: %v", event.PurchaseID, handler, attempt, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			s.logger.Errorf("purchase %s confirmed: %T gave up after %d attempts: %v", event.PurchaseID, handler, attempt, ctx.Err())
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// Shutdown stops firing the events and waits for the running handlers to
+// complete. Once ctx is done, their calls and retries are canceled.
+func (s *service) Shutdown(ctx context.Context) error {
+	return s.events.close(ctx)
+}
@@ -0,0 +1,106 @@
+package mercadolivre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingHandler fails the first failures calls, with err if it is set.
+type failingHandler struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+	err      error
+}
+
+func (h *failingHandler) HandlePurchaseConfirmed(ctx context.Context, event PurchaseConfirmed) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	if h.calls <= h.failures {
+		if h.err != nil {
+			return h.err
+		}
+		return errors.New("unavailable")
+	}
+	return nil
+}
+
+func (h *failingHandler) callCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+func newEventsTestService(t *testing.T, handler PurchaseConfirmedHandler, backoff time.Duration) *service {
+	t.Helper()
+	svc, err := NewService(Config{
+		Repository:                NewMemoryRepository(),
+		JWT:                       JWTConfig{Secret: "test-secret"},
+		Storage:                   NewMemoryStorage(),
+		Mailer:                    NewFakeMailer(NewLogger(ErrorLevel)),
+		PurchaseConfirmedHandlers: []PurchaseConfirmedHandler{handler},
+		EventAttempts:             3,
+		EventBackoff:              backoff,
+	}, NewLogger(ErrorLevel))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return svc.(*service)
+}
+
+func TestShutdownWaitsForHandlers(t *testing.T) {
+	handler := &failingHandler{failures: 2}
+	svc := newEventsTestService(t, handler, time.Millisecond)
+
+	svc.firePurchaseConfirmed(context.Background(), PurchaseConfirmed{PurchaseID: "p"})
+	if err := svc.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if calls := handler.callCount(); calls != 3 {
+		t.Errorf("handler called %d times, want 3", calls)
+	}
+}
+
+func TestShutdownCancelsRetries(t *testing.T) {
+	handler := &failingHandler{failures: 3}
+	svc := newEventsTestService(t, handler, time.Hour)
+
+	svc.firePurchaseConfirmed(context.Background(), PurchaseConfirmed{PurchaseID: "p"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	if err := svc.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("Shutdown took %s, want the retry canceled", elapsed)
+	}
+	if calls := handler.callCount(); calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+
+	svc.firePurchaseConfirmed(context.Background(), PurchaseConfirmed{PurchaseID: "q"})
+	if calls := handler.callCount(); calls != 1 {
+		t.Errorf("handler called %d times after Shutdown, want 1", calls)
+	}
+}
+
+func TestPermanentErrorsAreNotRetried(t *testing.T) {
+	handler := &failingHandler{failures: 3, err: fmt.Errorf("%w: 400 Bad Request", ErrPermanent)}
+	svc := newEventsTestService(t, handler, time.Hour)
+
+	svc.firePurchaseConfirmed(context.Background(), PurchaseConfirmed{PurchaseID: "p"})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := svc.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown error = %v, want nil without waiting for a retry", err)
+	}
+	if calls := handler.callCount(); calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,91 @@
+package mercadolivre
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+)
+
+// SignatureHeader is the header holding the signature of a gateway's callback.
+const SignatureHeader = "X-Signature"
+
+// Gateway represents a payment gateway.
+type Gateway string
+
+const (
+	GatewayPagSeguro Gateway = "pagseguro"
+	GatewayPaypal    Gateway = "paypal"
+)
+
+// RedirectURL returns the gateway's URL the buyer should be redirected to
+// in order to pay the purchase. After paying, the gateway sends the buyer
+// back to returnURL.
+func (g Gateway) RedirectURL(purchaseID, returnURL string) (string, error) {
+	switch g {
+	case GatewayPagSeguro:
+		q := url.Values{}
+		q.Set("returnId", purchaseID)
+		q.Set("redirectUrl", returnURL)
+		return fmt.Sprintf("https://pagseguro.com?%s", q.Encode()), nil
+	case GatewayPaypal:
+		q := url.Values{}
+		q.Set("redirectUrl", returnURL)
+		return fmt.Sprintf("https://paypal.com/%s?%s", url.PathEscape(purchaseID), q.Encode()), nil
+	}
+	return "", fmt.Errorf("%w: gateway %s", ErrIsNotValid, g)
+}
+
+// TransactionStatus represents the normalized result of a payment attempt.
+type TransactionStatus string
+
+const (
+	TransactionStatusFailure TransactionStatus = "failure"
+	TransactionStatusSuccess TransactionStatus = "success"
+)
+
+// TransactionStatus normalizes the status the gateway sends in its callback.
+func (g Gateway) TransactionStatus(status string) (TransactionStatus, error) {
+	var statuses map[string]TransactionStatus
+	switch g {
+	case GatewayPagSeguro:
+		statuses = map[string]TransactionStatus{
+			"ERRO":    TransactionStatusFailure,
+			"SUCESSO": TransactionStatusSuccess,
+		}
+	case GatewayPaypal:
+		statuses = map[string]TransactionStatus{
+			"0": TransactionStatusFailure,
+			"1": TransactionStatusSuccess,
+		}
+	default:
+		return "", fmt.Errorf("%w: gateway %s", ErrIsNotValid, g)
+	}
+	if s, ok := statuses[status]; ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("%w: %s's status %s", ErrIsNotValid, g, status)
+}
+
+// SignCallback returns the signature of a callback's body: its HMAC-SHA256
+// with the gateway's secret, hex encoded.
+func SignCallback(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyCallback checks the signature of the gateway's callback body.
+func verifyCallback(secret string, body []byte, signature string) error {
+	if secret == "" {
+		return fmt.Errorf("%w: callbacks are not configured", ErrAuthFailed)
+	}
+	if signature == "" {
+		return fmt.Errorf("%w: %s header", ErrMissingToken, SignatureHeader)
+	}
+	if !hmac.Equal([]byte(SignCallback(secret, body)), []byte(signature)) {
+		return fmt.Errorf("%w: %s header", ErrAuthFailed, SignatureHeader)
+	}
+	return nil
+}
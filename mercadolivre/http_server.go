@@ -1,21 +1,42 @@
 package mercadolivre
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/handlers"
 )
 
+const (
+	defaultReadTimeout       = 30 * time.Second
+	defaultReadHeaderTimeout = 5 * time.Second
+	defaultWriteTimeout      = 60 * time.Second
+	defaultIdleTimeout       = 2 * time.Minute
+	defaultShutdownTimeout   = 30 * time.Second
+)
+
 type httpServer struct {
-	logger Logger
+	logger   Logger
+	storage  Storage
+	standIns bool
+	jwt      JWTConfig
+	cookies  CookieConfig
+	timeouts TimeoutConfig
+	metrics  *Metrics
+
+	gatewaySecrets map[Gateway]string
 }
 
-// NewHTTPServer starts new HTTP server
-func NewHTTPServer(cfg Config, svc Service, logger Logger) error {
+// NewHTTPServer starts new HTTP server and, if cfg.MetricsServer.Port is
+// set, the metrics listener, and serves until ctx is done. The servers then
+// stop accepting requests and wait for the in-flight ones, and svc for its
+// event handlers, to complete, for up to cfg.Server.ShutdownTimeout.
+func NewHTTPServer(ctx context.Context, cfg Config, svc Service, logger Logger) error {
 	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
 	lnAddr, err := net.ResolveTCPAddr("tcp", addr)
 	if err != nil {
@@ -23,14 +44,78 @@ func NewHTTPServer(cfg Config, svc Service, logger Logger) error {
 	}
 
 	srv := &httpServer{
-		logger: logger,
+		logger:   logger,
+		storage:  cfg.Storage,
+		standIns: cfg.StandIns,
+		jwt:      cfg.JWT,
+		cookies:  cfg.Cookies,
+		timeouts: cfg.Timeouts,
+		metrics:  cfg.Metrics,
+
+		gatewaySecrets: cfg.GatewaySecrets,
 	}
-	router := srv.MakeHTTPHandler(svc)
-	loggingHandler := handlers.LoggingHandler(os.Stdout, router)
-	fmt.Printf("HTTP server listening on http://%s\n", lnAddr.String())
-	if err := http.ListenAndServe(lnAddr.String(), loggingHandler); err != nil {
+	if srv.metrics == nil {
+		srv.metrics = NewDiscardMetrics()
+	}
+	router, err := srv.MakeHTTPHandler(svc)
+	if err != nil {
 		return err
 	}
+	loggingHandler := handlers.LoggingHandler(os.Stdout, router)
+	server := &http.Server{
+		Addr:              lnAddr.String(),
+		Handler:           loggingHandler,
+		ReadTimeout:       durationOr(cfg.Server.ReadTimeout, defaultReadTimeout),
+		ReadHeaderTimeout: durationOr(cfg.Server.ReadHeaderTimeout, defaultReadHeaderTimeout),
+		WriteTimeout:      durationOr(cfg.Server.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:       durationOr(cfg.Server.IdleTimeout, defaultIdleTimeout),
+	}
 
-	return nil
+	servers := []*http.Server{server}
+	if cfg.MetricsServer.Port != 0 {
+		metricsAddr := net.JoinHostPort(cfg.MetricsServer.Host, strconv.Itoa(cfg.MetricsServer.Port))
+		metricsRouter := http.NewServeMux()
+		metricsRouter.Handle("/metrics", srv.metrics.Handler())
+		servers = append(servers, &http.Server{
+			Addr:              metricsAddr,
+			Handler:           metricsRouter,
+			ReadHeaderTimeout: server.ReadHeaderTimeout,
+			WriteTimeout:      server.WriteTimeout,
+			IdleTimeout:       server.IdleTimeout,
+		})
+	}
+
+	errs := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s *http.Server) {
+			fmt.Printf("HTTP server listening on http://%s\n", s.Addr)
+			errs <- s.ListenAndServe()
+		}(s)
+	}
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+	}
+
+	shutdownTimeout := durationOr(cfg.Server.ShutdownTimeout, defaultShutdownTimeout)
+	logger.Infof("HTTP server shutting down, waiting up to %s for the in-flight requests", shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, s := range servers {
+		if e := s.Shutdown(shutdownCtx); e != nil && err == nil {
+			err = fmt.Errorf("could not shut down the HTTP server: %w", e)
+		}
+	}
+	if e := svc.Shutdown(shutdownCtx); e != nil && err == nil {
+		err = fmt.Errorf("could not shut down the service: %w", e)
+	}
+	return err
+}
+
+// durationOr returns d or, if d is not positive, def.
+func durationOr(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
 }
@@ -0,0 +1,198 @@
+package mercadolivre
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	jwtKit "github.com/go-kit/kit/auth/jwt"
+)
+
+const (
+	// defaultTokenTTL is how long a token is valid for.
+	defaultTokenTTL = 5 * time.Minute
+	// defaultRefreshWindow is how long before expiring a token can be refreshed.
+	defaultRefreshWindow = 30 * time.Second
+	// defaultRefreshTokenTTL is how long a refresh token is valid for.
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// JWTConfig is used to configure how the tokens are signed.
+type JWTConfig struct {
+	// Algorithm defines the signing algorithm: HS256 (default), RS256 or ES256.
+	Algorithm string
+	// Secret defines the HS256 signing key.
+	Secret string
+	// PrivateKeyFile defines the PEM file holding the RS256 or ES256 signing key.
+	PrivateKeyFile string
+	// KeyID defines the kid header of the tokens and of the published key.
+	// If empty, it is derived from the public key.
+	KeyID string
+	// TTL defines how long a token is valid for.
+	TTL time.Duration
+	// RefreshWindow defines how long before expiring a token can be refreshed.
+	RefreshWindow time.Duration
+	// RefreshTokenTTL defines how long a refresh token is valid for.
+	RefreshTokenTTL time.Duration
+}
+
+// jwtKeys signs and verifies the tokens.
+type jwtKeys struct {
+	method          jwt.SigningMethod
+	signKey         interface{}
+	verifyKey       interface{}
+	keyID           string
+	ttl             time.Duration
+	refreshWindow   time.Duration
+	refreshTokenTTL time.Duration
+}
+
+func newJWTKeys(cfg JWTConfig) (*jwtKeys, error) {
+	keys := &jwtKeys{
+		keyID:           cfg.KeyID,
+		ttl:             cfg.TTL,
+		refreshWindow:   cfg.RefreshWindow,
+		refreshTokenTTL: cfg.RefreshTokenTTL,
+	}
+	if keys.ttl <= 0 {
+		keys.ttl = defaultTokenTTL
+	}
+	if keys.refreshWindow <= 0 {
+		keys.refreshWindow = defaultRefreshWindow
+	}
+	if keys.refreshTokenTTL <= 0 {
+		keys.refreshTokenTTL = defaultRefreshTokenTTL
+	}
+
+	switch cfg.Algorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+		if cfg.Secret == "" {
+			return nil, errors.New("jwt: secret should be configured")
+		}
+		keys.method = jwt.SigningMethodHS256
+		keys.signKey = []byte(cfg.Secret)
+		keys.verifyKey = []byte(cfg.Secret)
+		return keys, nil
+	case jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg():
+	default:
+		return nil, fmt.Errorf("jwt: algorithm %s is not supported", cfg.Algorithm)
+	}
+
+	if cfg.PrivateKeyFile == "" {
+		return nil, fmt.Errorf("jwt: private key file should be configured for %s", cfg.Algorithm)
+	}
+	pem, err := ioutil.ReadFile(cfg.PrivateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: %w", err)
+	}
+	if cfg.Algorithm == jwt.SigningMethodRS256.Alg() {
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+		keys.method = jwt.SigningMethodRS256
+		keys.signKey = key
+		keys.verifyKey = &key.PublicKey
+	} else {
+		key, err := jwt.ParseECPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+		if key.Curve.Params().BitSize != 256 {
+			return nil, errors.New("jwt: ES256 requires a P-256 key")
+		}
+		keys.method = jwt.SigningMethodES256
+		keys.signKey = key
+		keys.verifyKey = &key.PublicKey
+	}
+
+	if keys.keyID == "" {
+		der, err := x509.MarshalPKIXPublicKey(keys.verifyKey)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+		sum := sha256.Sum256(der)
+		keys.keyID = base64.RawURLEncoding.EncodeToString(sum[:12])
+	}
+	return keys, nil
+}
+
+// sign signs claims.
+func (k *jwtKeys) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.keyID != "" {
+		token.Header["kid"] = k.keyID
+	}
+	return token.SignedString(k.signKey)
+}
+
+// keyFunc returns the key used to verify token.
+func (k *jwtKeys) keyFunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, jwtKit.ErrUnexpectedSigningMethod
+	}
+	return k.verifyKey, nil
+}
+
+// JWKS is a JSON Web Key Set as defined in RFC 7517.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a JSON Web Key as defined in RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// jwks returns the public keys. Symmetric keys are never published.
+func (k *jwtKeys) jwks() *JWKS {
+	set := &JWKS{Keys: []JWK{}}
+	switch key := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		set.Keys = append(set.Keys, JWK{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: k.method.Alg(),
+			Kid: k.keyID,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		set.Keys = append(set.Keys, JWK{
+			Kty: "EC",
+			Use: "sig",
+			Alg: k.method.Alg(),
+			Kid: k.keyID,
+			Crv: key.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(padLeft(key.X.Bytes(), size)),
+			Y:   base64.RawURLEncoding.EncodeToString(padLeft(key.Y.Bytes(), size)),
+		})
+	}
+	return set
+}
+
+func padLeft(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
@@ -88,6 +88,9 @@ type Logger interface {
 	// Fatalw logs a message with some additional context, then calls os.Exit. The
 	// variadic key-value pairs are treated as they are in With.
 	Fatalw(msg string, keysAndValues ...interface{})
+	// Sync flushes any buffered log entries. It should be called before
+	// exiting.
+	Sync() error
 }
 
 func NewLogger(level Level) Logger {
@@ -103,11 +106,5 @@ func NewLogger(level Level) Logger {
 	if err != nil {
 		log.Fatalf("could not initialize zap logger: %v", err)
 	}
-	sugar := zapLogger.Sugar()
-	defer func() {
-		if err := sugar.Sync(); err != nil {
-			log.Printf("could not terminate zap logger: %v", err)
-		}
-	}()
-	return sugar
+	return zapLogger.Sugar()
 }
@@ -0,0 +1,300 @@
+package mercadolivre
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Mail represents a single e-mail message.
+type Mail struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends e-mail notifications.
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
+
+type fakeMailer struct {
+	logger Logger
+}
+
+// NewFakeMailer creates a Mailer that only logs the messages.
+func NewFakeMailer(logger Logger) Mailer {
+	return &fakeMailer{
+		logger: logger,
+	}
+}
+
+// Send logs mail.
+func (f *fakeMailer) Send(_ context.Context, mail Mail) error {
+	f.logger.Infow("mail sent",
+		"to", strings.Join(mail.To, ", "),
+		"subject", mail.Subject,
+		"body", mail.Body,
+	)
+	return nil
+}
+
+// defaultSMTPTimeout is how long sending a mail may take by default.
+const defaultSMTPTimeout = 10 * time.Second
+
+// SMTPConfig is used to configure the SMTP server the mails are sent through.
+type SMTPConfig struct {
+	// Addr defines the SMTP server's host:port.
+	Addr string
+	// From defines the sender's address.
+	From string
+	// Username defines the PLAIN authentication's user. If empty, the
+	// server is not authenticated to.
+	Username string
+	// Password defines the PLAIN authentication's password.
+	Password string
+	// Timeout defines how long sending a mail may take, 10s by default.
+	Timeout time.Duration
+}
+
+type smtpMailer struct {
+	addr    string
+	host    string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+// NewSMTPMailer creates a Mailer that sends the messages through the SMTP
+// server configured by cfg.
+func NewSMTPMailer(cfg SMTPConfig) (Mailer, error) {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp: %w", err)
+	}
+	m := &smtpMailer{
+		addr:    cfg.Addr,
+		host:    host,
+		from:    cfg.From,
+		timeout: durationOr(cfg.Timeout, defaultSMTPTimeout),
+	}
+	if cfg.Username != "" {
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return m, nil
+}
+
+// Send sends mail through the SMTP server. Sending is given up once ctx is
+// done or the timeout is exceeded.
+func (m *smtpMailer) Send(ctx context.Context, mail Mail) error {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Closing conn interrupts the exchange once ctx is canceled.
+	sent := make(chan struct{})
+	defer close(sent)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-sent:
+		}
+	}()
+
+	if err := m.send(conn, mail); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		}
+		return err
+	}
+	return nil
+}
+
+// send sends mail over conn, as smtp.SendMail does.
+func (m *smtpMailer) send(conn net.Conn, mail Mail) error {
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.from); err != nil {
+		return err
+	}
+	for _, to := range mail.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(m.message(mail)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message returns mail formatted as an RFC 5322 message.
+func (m *smtpMailer) message(mail Mail) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(mail.To, ", "))
+	// The subject is encoded, so that its line breaks cannot add headers.
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	return msg.Bytes()
+}
+
+// SMTPStandIn stands in for an SMTP server, passing every mail it receives
+// to a Mailer, such as the fake one logging them. It supports neither TLS
+// nor authentication.
+type SMTPStandIn struct {
+	deliver Mailer
+	logger  Logger
+}
+
+// NewSMTPStandIn creates an SMTPStandIn delivering the mails to deliver.
+func NewSMTPStandIn(deliver Mailer, logger Logger) *SMTPStandIn {
+	return &SMTPStandIn{
+		deliver: deliver,
+		logger:  logger,
+	}
+}
+
+// Serve accepts the SMTP connections on ln until it is closed.
+func (s *SMTPStandIn) Serve(ln net.Listener) error {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *SMTPStandIn) serveConn(conn net.Conn) {
+	defer conn.Close()
+	tc := textproto.NewConn(conn)
+	reply := func(code int, msg string) {
+		if err := tc.PrintfLine("%d %s", code, msg); err != nil {
+			s.logger.Warnf("smtp stand-in: %v", err)
+		}
+	}
+
+	reply(220, "mercadolivre SMTP stand-in")
+	var to []string
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			verb, arg = line[:i], line[i+1:]
+		}
+		switch strings.ToUpper(verb) {
+		case "HELO", "EHLO", "NOOP":
+			reply(250, "OK")
+		case "MAIL", "RSET":
+			to = nil
+			reply(250, "OK")
+		case "RCPT":
+			addr, ok := smtpPath(arg, "TO:")
+			if !ok {
+				reply(501, "Syntax error")
+				continue
+			}
+			to = append(to, addr)
+			reply(250, "OK")
+		case "DATA":
+			reply(354, "End data with <CR><LF>.<CR><LF>")
+			data, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			if err := s.receive(to, data); err != nil {
+				reply(554, err.Error())
+				continue
+			}
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "Bye")
+			return
+		default:
+			reply(502, "Command not implemented")
+		}
+	}
+}
+
+// receive delivers the received message data to the recipients to.
+func (s *SMTPStandIn) receive(to []string, data []byte) error {
+	msg, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		return err
+	}
+	body, err := ioutil.ReadAll(msg.Body)
+	if err != nil {
+		return err
+	}
+	return s.deliver.Send(context.Background(), Mail{
+		To:      to,
+		Subject: subject,
+		// The DATA command ends the body with a line break.
+		Body: strings.TrimSuffix(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n"),
+	})
+}
+
+// smtpPath returns the address of a MAIL FROM:<address> or RCPT TO:<address>
+// command's argument, which starts with prefix.
+func smtpPath(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if i := strings.IndexByte(path, ' '); i >= 0 {
+		path = path[:i]
+	}
+	if !strings.HasPrefix(path, "<") || !strings.HasSuffix(path, ">") {
+		return "", false
+	}
+	return path[1 : len(path)-1], true
+}
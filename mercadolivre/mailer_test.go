@@ -0,0 +1,92 @@
+package mercadolivre
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recordingMailer records the mails it is given.
+type recordingMailer struct {
+	mu    sync.Mutex
+	mails []Mail
+}
+
+func (r *recordingMailer) Send(_ context.Context, mail Mail) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mails = append(r.mails, mail)
+	return nil
+}
+
+// take returns the recorded mails and forgets them.
+func (r *recordingMailer) take() []Mail {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mails := r.mails
+	r.mails = nil
+	return mails
+}
+
+func TestSMTPMailerWithStandIn(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+	received := &recordingMailer{}
+	go NewSMTPStandIn(received, NewLogger(ErrorLevel)).Serve(ln)
+
+	mailer, err := NewSMTPMailer(SMTPConfig{Addr: ln.Addr().String(), From: "noreply@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTPMailer: %v", err)
+	}
+	tests := []struct {
+		name string
+		mail Mail
+	}{
+		{"plain", Mail{To: []string{"owner@example.com"}, Subject: "New question about Book", Body: "Is it new?\n\nThanks"}},
+		{"accented", Mail{To: []string{"owner@example.com", "other@example.com"}, Subject: "Nova pergunta sobre o Livro à venda", Body: "Está novo?"}},
+		{"line break in the subject", Mail{To: []string{"owner@example.com"}, Subject: "Book\r\nBcc: victim@example.com", Body: "body"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := mailer.Send(context.Background(), tt.mail); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if got, want := received.take(), []Mail{tt.mail}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("received %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerContextCanceled(t *testing.T) {
+	// The server accepts the connection but never greets.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	mailer, err := NewSMTPMailer(SMTPConfig{Addr: ln.Addr().String(), From: "noreply@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTPMailer: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := mailer.Send(ctx, Mail{To: []string{"owner@example.com"}}); err == nil {
+		t.Fatal("Send succeeded, want the context's error")
+	}
+}
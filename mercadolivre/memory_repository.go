@@ -0,0 +1,777 @@
+package mercadolivre
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mu            sync.RWMutex
+	users         map[string]User
+	refreshTokens map[string]RefreshToken
+	categories    map[string]Category
+	products      map[string]Product
+	purchases     map[string]Purchase
+	transactions  []Transaction
+	opinions      []Opinion
+	questions     []Question
+}
+
+// NewMemoryRepository creates a Repository keeping everything in memory.
+// It is safe for concurrent use.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		users:         map[string]User{},
+		refreshTokens: map[string]RefreshToken{},
+		categories:    map[string]Category{},
+		products:      map[string]Product{},
+		purchases:     map[string]Purchase{},
+	}
+}
+
+// memoryColumns are the columns Exists looks up, with the value and the
+// ID of each row.
+var memoryColumns = map[string]func(r *memoryRepository, visit func(value, id string)){
+	"categories.id": func(r *memoryRepository, visit func(value, id string)) {
+		for id := range r.categories {
+			visit(id, id)
+		}
+	},
+	"categories.name": func(r *memoryRepository, visit func(value, id string)) {
+		for id, category := range r.categories {
+			visit(category.Name, id)
+		}
+	},
+	"products.id": func(r *memoryRepository, visit func(value, id string)) {
+		for id, product := range r.products {
+			if !product.DeletedAt.Valid {
+				visit(id, id)
+			}
+		}
+	},
+	"purchases.id": func(r *memoryRepository, visit func(value, id string)) {
+		for id := range r.purchases {
+			visit(id, id)
+		}
+	},
+	"users.name": func(r *memoryRepository, visit func(value, id string)) {
+		for id, user := range r.users {
+			visit(user.Name, id)
+		}
+	},
+}
+
+func (r *memoryRepository) Columns(ctx context.Context) (map[string]bool, error) {
+	columns := make(map[string]bool, len(memoryColumns))
+	for column := range memoryColumns {
+		columns[column] = true
+	}
+	return columns, nil
+}
+
+func (r *memoryRepository) Exists(ctx context.Context, column, value, exceptID string) (bool, error) {
+	rows, ok := memoryColumns[column]
+	if !ok {
+		return false, fmt.Errorf("%w: column %q", ErrIsNotValid, column)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	exists := false
+	rows(r, func(v, id string) {
+		if v == value && id != exceptID {
+			exists = true
+		}
+	})
+	return exists, nil
+}
+
+func (r *memoryRepository) CreateUser(ctx context.Context, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Name == user.Name {
+			return fmt.Errorf("%w: user %s", ErrAlreadyExists, user.Name)
+		}
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memoryRepository) User(ctx context.Context, id string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: user %s", ErrNotFound, id)
+	}
+	return &user, nil
+}
+
+func (r *memoryRepository) UserByName(ctx context.Context, name string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Name == name {
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: user %s", ErrNotFound, name)
+}
+
+func (r *memoryRepository) CreateRefreshToken(ctx context.Context, token RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshTokens[token.ID] = token
+	return nil
+}
+
+// refreshToken returns the refresh token hashed as tokenHash.
+// The caller must hold r.mu.
+func (r *memoryRepository) refreshToken(tokenHash string) (RefreshToken, error) {
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return RefreshToken{}, fmt.Errorf("%w: refresh token", ErrNotFound)
+}
+
+// revokeRefreshTokens revokes the refresh tokens matching revoke.
+// The caller must hold r.mu.
+func (r *memoryRepository) revokeRefreshTokens(now time.Time, revoke func(RefreshToken) bool) {
+	for id, token := range r.refreshTokens {
+		if !token.RevokedAt.Valid && revoke(token) {
+			token.RevokedAt = sql.NullTime{Time: now, Valid: true}
+			r.refreshTokens[id] = token
+		}
+	}
+}
+
+func (r *memoryRepository) RotateRefreshToken(ctx context.Context, tokenHash string, next RefreshToken) (*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, err := r.refreshToken(tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if current.RevokedAt.Valid {
+		r.revokeRefreshTokens(next.CreatedAt, func(token RefreshToken) bool {
+			return token.FamilyID == current.FamilyID
+		})
+		return nil, errRefreshTokenReused
+	}
+	if !current.ExpiresAt.After(next.CreatedAt) {
+		return nil, errRefreshTokenExpired
+	}
+
+	next.FamilyID = current.FamilyID
+	next.UserID = current.UserID
+	r.refreshTokens[next.ID] = next
+	current.RevokedAt = sql.NullTime{Time: next.CreatedAt, Valid: true}
+	current.ReplacedBy = sql.NullString{String: next.ID, Valid: true}
+	r.refreshTokens[current.ID] = current
+	return &next, nil
+}
+
+func (r *memoryRepository) RevokeRefreshTokens(ctx context.Context, tokenHash string, all bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, err := r.refreshToken(tokenHash)
+	if err != nil {
+		return err
+	}
+	r.revokeRefreshTokens(time.Now(), func(token RefreshToken) bool {
+		if all {
+			return token.UserID == current.UserID
+		}
+		return token.FamilyID == current.FamilyID
+	})
+	return nil
+}
+
+func (r *memoryRepository) CreateCategory(ctx context.Context, category Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.categories[category.ID] = category
+	return nil
+}
+
+// categoryPath returns the Category's ancestors followed by the Category.
+// The caller must hold r.mu.
+func (r *memoryRepository) categoryPath(id string) []Category {
+	var path []Category
+	visited := map[string]bool{}
+	for category, ok := r.categories[id]; ok && !visited[category.ID]; category, ok = r.categories[category.ParentID.String] {
+		visited[category.ID] = true
+		path = append([]Category{category}, path...)
+	}
+	return path
+}
+
+func (r *memoryRepository) CategoryPath(ctx context.Context, id string) ([]Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.categoryPath(id), nil
+}
+
+// categorySubtree returns the Category and its descendants, unordered.
+// The caller must hold r.mu.
+func (r *memoryRepository) categorySubtree(id string) []Category {
+	root, ok := r.categories[id]
+	if !ok {
+		return nil
+	}
+	subtree := []Category{root}
+	visited := map[string]bool{id: true}
+	for i := 0; i < len(subtree); i++ {
+		for _, category := range r.categories {
+			if category.ParentID.String == subtree[i].ID && !visited[category.ID] {
+				visited[category.ID] = true
+				subtree = append(subtree, category)
+			}
+		}
+	}
+	return subtree
+}
+
+func (r *memoryRepository) CategorySubtree(ctx context.Context, id string) ([]Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	subtree := r.categorySubtree(id)
+	sort.Slice(subtree, func(i, j int) bool {
+		return subtree[i].Name < subtree[j].Name
+	})
+	return subtree, nil
+}
+
+func (r *memoryRepository) Categories(ctx context.Context) ([]CategoryListResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	categories := make([]CategoryListResponse, 0, len(r.categories))
+	for _, category := range r.categories {
+		categories = append(categories, CategoryListResponse{
+			ID:            category.ID,
+			Name:          category.Name,
+			ParentID:      category.ParentID.String,
+			ProductsCount: r.productsIn(category.ID),
+		})
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Name < categories[j].Name
+	})
+	return categories, nil
+}
+
+// productsIn returns the number of Products in the Category.
+// The caller must hold r.mu.
+func (r *memoryRepository) productsIn(categoryID string) int {
+	n := 0
+	for _, product := range r.products {
+		if product.CategoryID == categoryID && !product.DeletedAt.Valid {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *memoryRepository) UpdateCategory(ctx context.Context, update CategoryUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	category, ok := r.categories[update.ID]
+	if !ok {
+		return fmt.Errorf("%w: category %s", ErrNotFound, update.ID)
+	}
+	if update.ParentID != nil {
+		for _, ancestor := range r.categoryPath(*update.ParentID) {
+			if ancestor.ID == update.ID {
+				return fmt.Errorf("%w: category %s under %s", ErrCreatesCycle, update.ID, *update.ParentID)
+			}
+		}
+		category.ParentID = sql.NullString{
+			String: *update.ParentID,
+			Valid:  *update.ParentID != "",
+		}
+	}
+	if update.Name != nil {
+		category.Name = *update.Name
+	}
+	r.categories[update.ID] = category
+	return nil
+}
+
+func (r *memoryRepository) DeleteCategory(ctx context.Context, id, reassignTo string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.categories[id]; !ok {
+		return fmt.Errorf("%w: category %s", ErrNotFound, id)
+	}
+	for _, category := range r.categories {
+		if category.ParentID.String == id {
+			return fmt.Errorf("%w: category %s", ErrHasChildren, id)
+		}
+	}
+	if reassignTo == "" && r.productsIn(id) > 0 {
+		return fmt.Errorf("%w: category %s", ErrInUse, id)
+	}
+	for productID, product := range r.products {
+		if product.CategoryID == id {
+			product.CategoryID = reassignTo
+			r.products[productID] = product
+		}
+	}
+	delete(r.categories, id)
+	return nil
+}
+
+func (r *memoryRepository) CreateProduct(ctx context.Context, product Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product.Features = append([]Feature(nil), product.Features...)
+	r.products[product.ID] = product
+	return nil
+}
+
+// product returns a copy of the Product, which is not deleted.
+// The caller must hold r.mu.
+func (r *memoryRepository) product(id string) (*Product, error) {
+	product, ok := r.products[id]
+	if !ok || product.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: product %s", ErrNotFound, id)
+	}
+	product.Features = append([]Feature(nil), product.Features...)
+	sort.SliceStable(product.Features, func(i, j int) bool {
+		if product.Features[i].Type != product.Features[j].Type {
+			return product.Features[i].Type < product.Features[j].Type
+		}
+		return product.Features[i].Name < product.Features[j].Name
+	})
+	product.Images = append([]string(nil), product.Images...)
+	return &product, nil
+}
+
+func (r *memoryRepository) Product(ctx context.Context, id string) (*Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.product(id)
+}
+
+func (r *memoryRepository) Products(ctx context.Context, offset, limit int) ([]Product, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var products []Product
+	for _, product := range r.products {
+		if !product.DeletedAt.Valid {
+			products = append(products, product)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		if !products[i].CreatedAt.Equal(products[j].CreatedAt) {
+			return products[i].CreatedAt.After(products[j].CreatedAt)
+		}
+		return products[i].ID < products[j].ID
+	})
+	total := len(products)
+	if offset > total {
+		offset = total
+	}
+	if offset+limit < total {
+		products = products[offset : offset+limit]
+	} else {
+		products = products[offset:]
+	}
+	return products, total, nil
+}
+
+// ownedProduct returns the Product unless it is not owned by ownerID.
+// The caller must hold r.mu.
+func (r *memoryRepository) ownedProduct(ownerID, id string) (*Product, error) {
+	product, err := r.product(id)
+	if err != nil {
+		return nil, err
+	}
+	if product.OwnerID != ownerID {
+		return nil, fmt.Errorf("%w: only the owner can change product %s", ErrForbidden, id)
+	}
+	return product, nil
+}
+
+func (r *memoryRepository) CheckProductOwner(ctx context.Context, ownerID, id string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, err := r.ownedProduct(ownerID, id)
+	return err
+}
+
+func (r *memoryRepository) UpdateProduct(ctx context.Context, update ProductUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, err := r.ownedProduct(update.OwnerID, update.ID)
+	if err != nil {
+		return err
+	}
+	if update.Name != nil {
+		product.Name = *update.Name
+	}
+	if update.Price != nil {
+		product.Price = *update.Price
+	}
+	if update.Amount != nil {
+		product.Amount = *update.Amount
+	}
+	if update.Desc != nil {
+		product.Desc = *update.Desc
+	}
+	if update.CategoryID != nil {
+		product.CategoryID = *update.CategoryID
+	}
+	if update.Features != nil {
+		product.Features = append([]Feature(nil), update.Features...)
+	}
+	r.products[update.ID] = *product
+	return nil
+}
+
+func (r *memoryRepository) DeleteProduct(ctx context.Context, ownerID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, err := r.ownedProduct(ownerID, id)
+	if err != nil {
+		return err
+	}
+	product.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	r.products[id] = *product
+	return nil
+}
+
+func (r *memoryRepository) AddProductImages(ctx context.Context, ownerID, id string, urls []string) (*Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, err := r.ownedProduct(ownerID, id)
+	if err != nil {
+		return nil, err
+	}
+	product.Images = append(product.Images, urls...)
+	r.products[id] = *product
+	return r.product(id)
+}
+
+// memorySearchMatch is a Product matching a search, with its average rating
+// and its value of the search's sort.
+type memorySearchMatch struct {
+	product Product
+	rating  float64
+	value   float64
+}
+
+// searchMatches returns the Products matching search.
+// The caller must hold r.mu.
+func (r *memoryRepository) searchMatches(search ProductSearch) []memorySearchMatch {
+	var categories map[string]bool
+	if search.CategoryID != "" {
+		categories = map[string]bool{}
+		for _, category := range r.categorySubtree(search.CategoryID) {
+			categories[category.ID] = true
+		}
+	}
+	sums, counts := map[string]int{}, map[string]int{}
+	for _, opinion := range r.opinions {
+		sums[opinion.ProductID] += opinion.Rating
+		counts[opinion.ProductID]++
+	}
+	terms := strings.Fields(strings.ToLower(search.Query))
+
+	var matches []memorySearchMatch
+	for _, product := range r.products {
+		var rating float64
+		if counts[product.ID] > 0 {
+			rating = float64(sums[product.ID]) / float64(counts[product.ID])
+		}
+		rank, ok := searchRank(product, terms)
+		switch {
+		case product.DeletedAt.Valid, !ok,
+			categories != nil && !categories[product.CategoryID],
+			search.MinPrice != nil && float64(product.Price) < *search.MinPrice,
+			search.MaxPrice != nil && float64(product.Price) > *search.MaxPrice,
+			search.MinRating != nil && rating < *search.MinRating,
+			search.InStock && product.Amount <= 0,
+			!hasFacets(product, search.Facets):
+			continue
+		}
+
+		value := rank
+		switch search.Sort {
+		case SortNewest:
+			// Microseconds are exact in a float64, as in the timestamps.
+			value = float64(product.CreatedAt.UnixNano() / int64(time.Microsecond))
+		case SortPriceAsc, SortPriceDesc:
+			value = float64(product.Price)
+		}
+		matches = append(matches, memorySearchMatch{product: product, rating: rating, value: value})
+	}
+	return matches
+}
+
+// searchRank reports whether the Product contains every term and ranks it,
+// weighting the terms found in its name over its description over its
+// Features, as the search column does.
+func searchRank(product Product, terms []string) (float64, bool) {
+	name, desc := strings.ToLower(product.Name), strings.ToLower(product.Desc)
+	var features strings.Builder
+	for _, feature := range product.Features {
+		features.WriteString(strings.ToLower(feature.Type + " " + feature.Name + " " + feature.Details + " "))
+	}
+	rank := 0.0
+	for _, term := range terms {
+		n := 3*strings.Count(name, term) + 2*strings.Count(desc, term) + strings.Count(features.String(), term)
+		if n == 0 {
+			return 0, false
+		}
+		rank += float64(n)
+	}
+	return rank, true
+}
+
+// hasFacets reports whether the Product has one of the names of every
+// Feature's type of facets.
+func hasFacets(product Product, facets map[string][]string) bool {
+	for typ, names := range facets {
+		found := false
+		for _, feature := range product.Features {
+			for _, name := range names {
+				found = found || (feature.Type == typ && feature.Name == name)
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// searchPrecedes reports whether the value and ID of a Product come before
+// those of another in the search's order.
+func searchPrecedes(value float64, id string, otherValue float64, otherID string, desc bool) bool {
+	if value != otherValue {
+		return (value < otherValue) != desc
+	}
+	return id != otherID && (id < otherID) != desc
+}
+
+func (r *memoryRepository) SearchProducts(ctx context.Context, search ProductSearch) ([]ProductSearchResult, error) {
+	var after float64
+	if search.After != nil {
+		var err error
+		if after, err = strconv.ParseFloat(search.After.Value, 64); err != nil {
+			return nil, fmt.Errorf("%w: cursor value %q", ErrIsNotValid, search.After.Value)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	matches := r.searchMatches(search)
+	desc := search.Sort != SortPriceAsc
+	sort.Slice(matches, func(i, j int) bool {
+		return searchPrecedes(matches[i].value, matches[i].product.ID, matches[j].value, matches[j].product.ID, desc)
+	})
+
+	results := []ProductSearchResult{}
+	for _, match := range matches {
+		if len(results) == search.Limit {
+			break
+		}
+		if search.After != nil && !searchPrecedes(after, search.After.ID, match.value, match.product.ID, desc) {
+			continue
+		}
+		results = append(results, ProductSearchResult{
+			ProductResponse: ProductResponse{
+				ID:         match.product.ID,
+				Name:       match.product.Name,
+				Price:      match.product.Price,
+				Amount:     match.product.Amount,
+				CategoryID: match.product.CategoryID,
+				CreatedAt:  match.product.CreatedAt,
+			},
+			AverageRating: match.rating,
+			CursorValue:   strconv.FormatFloat(match.value, 'f', -1, 64),
+		})
+	}
+	return results, nil
+}
+
+func (r *memoryRepository) SearchFacets(ctx context.Context, search ProductSearch) ([]Facet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	type facetKey struct{ typ, name string }
+	counts := map[facetKey]int{}
+	count := func(search ProductSearch, counted func(typ string) bool) {
+		for _, match := range r.searchMatches(search) {
+			seen := map[facetKey]bool{}
+			for _, feature := range match.product.Features {
+				key := facetKey{feature.Type, feature.Name}
+				if counted(feature.Type) && !seen[key] {
+					seen[key] = true
+					counts[key]++
+				}
+			}
+		}
+	}
+
+	count(search, func(typ string) bool {
+		_, filtered := search.Facets[typ]
+		return !filtered
+	})
+	for filtered := range search.Facets {
+		others := search
+		others.Facets = map[string][]string{}
+		for typ, names := range search.Facets {
+			if typ != filtered {
+				others.Facets[typ] = names
+			}
+		}
+		count(others, func(typ string) bool {
+			return typ == filtered
+		})
+	}
+
+	keys := make([]facetKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		switch {
+		case keys[i].typ != keys[j].typ:
+			return keys[i].typ < keys[j].typ
+		case counts[keys[i]] != counts[keys[j]]:
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i].name < keys[j].name
+	})
+	facets := []Facet{}
+	for _, key := range keys {
+		if len(facets) == 0 || facets[len(facets)-1].Type != key.typ {
+			facets = append(facets, Facet{Type: key.typ})
+		}
+		facet := &facets[len(facets)-1]
+		facet.Values = append(facet.Values, FacetValue{
+			Name:  key.name,
+			Count: counts[key],
+		})
+	}
+	return facets, nil
+}
+
+func (r *memoryRepository) CreatePurchase(ctx context.Context, purchase Purchase) (float32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, err := r.product(purchase.ProductID)
+	if err != nil || int(product.Amount) < purchase.Quantity {
+		return 0, fmt.Errorf("%w: product %s", ErrInsufficientStock, purchase.ProductID)
+	}
+	product.Amount -= int16(purchase.Quantity)
+	r.products[product.ID] = *product
+
+	purchase.Price = product.Price
+	r.purchases[purchase.ID] = purchase
+	return purchase.Price, nil
+}
+
+func (r *memoryRepository) RecordTransaction(ctx context.Context, transaction Transaction) (*Purchase, *Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	purchase, ok := r.purchases[transaction.PurchaseID]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: purchase %s", ErrNotFound, transaction.PurchaseID)
+	}
+	purchase.SellerID = r.products[purchase.ProductID].OwnerID
+	if purchase.Gateway != transaction.Gateway {
+		return &purchase, nil, fmt.Errorf("%w: purchase %s was started at %s", ErrIsNotValid, purchase.ID, purchase.Gateway)
+	}
+
+	transaction.Accepted = purchase.Status != PurchaseStatusPaid
+	r.transactions = append(r.transactions, transaction)
+	if transaction.Accepted && transaction.Status == TransactionStatusSuccess {
+		purchase.Status = PurchaseStatusPaid
+		r.purchases[purchase.ID] = purchase
+	}
+	return &purchase, &transaction, nil
+}
+
+func (r *memoryRepository) CreateOpinion(ctx context.Context, opinion Opinion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.opinions = append(r.opinions, opinion)
+	return nil
+}
+
+func (r *memoryRepository) ProductOpinions(ctx context.Context, productID string) ([]OpinionResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var opinions []OpinionResponse
+	for _, opinion := range r.opinions {
+		if opinion.ProductID != productID {
+			continue
+		}
+		opinions = append(opinions, OpinionResponse{
+			ID:        opinion.ID,
+			UserName:  r.users[opinion.UserID].Name,
+			Rating:    opinion.Rating,
+			Title:     opinion.Title,
+			Desc:      opinion.Desc,
+			CreatedAt: opinion.CreatedAt,
+		})
+	}
+	sort.SliceStable(opinions, func(i, j int) bool {
+		return opinions[i].CreatedAt.After(opinions[j].CreatedAt)
+	})
+	return opinions, nil
+}
+
+func (r *memoryRepository) ProductRating(ctx context.Context, productID string) (float64, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sum, count := 0, 0
+	for _, opinion := range r.opinions {
+		if opinion.ProductID == productID {
+			sum += opinion.Rating
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0, nil
+	}
+	return float64(sum) / float64(count), count, nil
+}
+
+func (r *memoryRepository) CreateQuestion(ctx context.Context, question Question) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.questions = append(r.questions, question)
+	return nil
+}
+
+func (r *memoryRepository) ProductQuestions(ctx context.Context, productID string) ([]QuestionResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var questions []QuestionResponse
+	for _, question := range r.questions {
+		if question.ProductID != productID {
+			continue
+		}
+		questions = append(questions, QuestionResponse{
+			ID:        question.ID,
+			UserName:  r.users[question.UserID].Name,
+			Title:     question.Title,
+			CreatedAt: question.CreatedAt,
+		})
+	}
+	sort.SliceStable(questions, func(i, j int) bool {
+		return questions[i].CreatedAt.Before(questions[j].CreatedAt)
+	})
+	return questions, nil
+}
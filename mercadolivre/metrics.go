@@ -0,0 +1,173 @@
+package mercadolivre
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes the metrics' names.
+const metricsNamespace = "mercadolivre"
+
+// codeOK labels the requests which succeeded.
+const codeOK = "ok"
+
+// Metrics records the requests, by endpoint, and the business events.
+type Metrics struct {
+	// Requests counts the requests by endpoint and error code.
+	Requests metrics.Counter
+	// Errors counts the failed requests by endpoint and error code.
+	Errors metrics.Counter
+	// Latency observes the requests' duration in seconds by endpoint.
+	Latency metrics.Histogram
+	// UsersCreated counts the created Users.
+	UsersCreated metrics.Counter
+	// ProductsCreated counts the created Products.
+	ProductsCreated metrics.Counter
+	// Purchases counts the Purchases entering a status, by gateway and status.
+	Purchases metrics.Counter
+	// Transactions counts the recorded Transactions, failed ones included,
+	// by gateway and status.
+	Transactions metrics.Counter
+
+	handler http.Handler
+}
+
+// NewDiscardMetrics creates Metrics recording nothing.
+func NewDiscardMetrics() *Metrics {
+	return &Metrics{
+		Requests:        discard.NewCounter(),
+		Errors:          discard.NewCounter(),
+		Latency:         discard.NewHistogram(),
+		UsersCreated:    discard.NewCounter(),
+		ProductsCreated: discard.NewCounter(),
+		Purchases:       discard.NewCounter(),
+		Transactions:    discard.NewCounter(),
+		handler:         http.NotFoundHandler(),
+	}
+}
+
+// NewPrometheusMetrics creates Metrics exposed to Prometheus along with the
+// Go runtime's, the process' and, if db is not nil, the db pool's.
+func NewPrometheusMetrics(db *sql.DB) *Metrics {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+	if db != nil {
+		registerDBStats(registry, db)
+	}
+
+	counter := func(name, help string, labels ...string) metrics.Counter {
+		cv := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      name,
+			Help:      help,
+		}, labels)
+		registry.MustRegister(cv)
+		return kitprometheus.NewCounter(cv)
+	}
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the requests in seconds, by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+	registry.MustRegister(latency)
+
+	return &Metrics{
+		Requests:        counter("requests_total", "Number of requests, by endpoint and error code.", "endpoint", "code"),
+		Errors:          counter("request_errors_total", "Number of failed requests, by endpoint and error code.", "endpoint", "code"),
+		Latency:         kitprometheus.NewHistogram(latency),
+		UsersCreated:    counter("users_created_total", "Number of created users."),
+		ProductsCreated: counter("products_created_total", "Number of created products."),
+		Purchases:       counter("purchases_total", "Number of purchases entering a status, by gateway and status.", "gateway", "status"),
+		Transactions:    counter("transactions_total", "Number of recorded payment transactions, by gateway and status.", "gateway", "status"),
+		handler:         promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}
+}
+
+// Handler serves the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return m.handler
+}
+
+// registerDBStats registers the db pool's sql.DBStats, read on every scrape.
+func registerDBStats(registry *prometheus.Registry, db *sql.DB) {
+	gauge := func(name, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return value(db.Stats())
+		})
+	}
+	counter := func(name, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return value(db.Stats())
+		})
+	}
+	registry.MustRegister(
+		gauge("max_open_connections", "Maximum number of open connections.", func(s sql.DBStats) float64 {
+			return float64(s.MaxOpenConnections)
+		}),
+		gauge("open_connections", "Number of established connections, in use or idle.", func(s sql.DBStats) float64 {
+			return float64(s.OpenConnections)
+		}),
+		gauge("in_use_connections", "Number of connections in use.", func(s sql.DBStats) float64 {
+			return float64(s.InUse)
+		}),
+		gauge("idle_connections", "Number of idle connections.", func(s sql.DBStats) float64 {
+			return float64(s.Idle)
+		}),
+		counter("wait_count_total", "Number of connections waited for.", func(s sql.DBStats) float64 {
+			return float64(s.WaitCount)
+		}),
+		counter("wait_duration_seconds_total", "Time spent waiting for a connection in seconds.", func(s sql.DBStats) float64 {
+			return s.WaitDuration.Seconds()
+		}),
+		counter("max_idle_closed_total", "Number of connections closed because of the maximum of idle connections.", func(s sql.DBStats) float64 {
+			return float64(s.MaxIdleClosed)
+		}),
+		counter("max_idle_time_closed_total", "Number of connections closed because of the maximum idle time.", func(s sql.DBStats) float64 {
+			return float64(s.MaxIdleTimeClosed)
+		}),
+		counter("max_lifetime_closed_total", "Number of connections closed because of the maximum lifetime.", func(s sql.DBStats) float64 {
+			return float64(s.MaxLifetimeClosed)
+		}),
+	)
+}
+
+// InstrumentingMdlwr records the requests to the named endpoint into m.
+func InstrumentingMdlwr(m *Metrics, name string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				code := codeOK
+				if err != nil {
+					code = string(catalogueEntryFrom(err).Code)
+					m.Errors.With("endpoint", name, "code", code).Add(1)
+				}
+				m.Requests.With("endpoint", name, "code", code).Add(1)
+				m.Latency.With("endpoint", name).Observe(time.Since(begin).Seconds())
+			}(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
@@ -2,19 +2,76 @@ package mercadolivre
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	jwtKit "github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
+	"github.com/lib/pq"
 )
 
+// NewAuthMdlwr authenticates the requests by parsing the tokens
+// signed as configured by cfg.
+func NewAuthMdlwr(cfg JWTConfig) (endpoint.Middleware, error) {
+	keys, err := newJWTKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return jwtKit.NewParser(keys.keyFunc, keys.method, jwtKit.StandardClaimsFactory), nil
+}
+
+// CallbackAuthMdlwr authenticates the payment gateways' callbacks by their
+// signatures, made with the gateways' secrets, before anything else is done.
+func CallbackAuthMdlwr(secrets map[Gateway]string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			req := request.(PaymentRequest)
+			if err := verifyCallback(secrets[Gateway(req.Gateway)], req.body, req.signature); err != nil {
+				return nil, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
 // ValidationMdlwr valides the requests.
 func ValidationMdlwr() endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 			req := request.(Request)
-			if err := req.Validate(); err != nil {
+			if err := req.Validate(ctx); err != nil {
 				return nil, err
 			}
 			return next(ctx, request)
 		}
 	}
 }
+
+// TimeoutMdlwr cancels the requests taking longer than d. If d is zero,
+// the requests have no deadline. A query canceled along with the request
+// fails with the reason: context.DeadlineExceeded once d is over and
+// context.Canceled when the client went away.
+func TimeoutMdlwr(d time.Duration) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			if d > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, d)
+				defer cancel()
+			}
+			response, err = next(ctx, request)
+			return response, contextError(ctx, err)
+		}
+	}
+}
+
+// contextError returns err along with ctx's error if err is the query lib/pq
+// canceled once ctx was done. Other errors are returned as is.
+func contextError(ctx context.Context, err error) error {
+	var pqErr *pq.Error
+	if ctx.Err() == nil || !errors.As(err, &pqErr) || pqErr.Code != queryCanceled {
+		return err
+	}
+	return fmt.Errorf("%w: %v", ctx.Err(), err)
+}
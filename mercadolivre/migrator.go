@@ -0,0 +1,290 @@
+package mercadolivre
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// migrationLockID is the PostgreSQL advisory lock held while migrating, so
+// that the servers booting together do not migrate concurrently.
+const migrationLockID int64 = 7423170241
+
+// migrationFile matches the golang-migrate style migration file names.
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a schema change with the SQL applying and reverting it.
+type Migration struct {
+	Version uint
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus is the state of the database schema.
+type MigrationStatus struct {
+	// Version is the last applied migration's version, 0 if none is.
+	Version uint
+	// Dirty reports a migration that failed midway, as left by golang-migrate.
+	// Migrations are not run while the schema is dirty: it should be fixed by
+	// hand and its version set with Force.
+	Dirty bool
+	// Migrations are all the known migrations, by version.
+	Migrations []Migration
+}
+
+// Migrator applies the migrations to a PostgreSQL database. The applied
+// version is kept in the schema_migrations table, as golang-migrate does.
+type Migrator struct {
+	db         *sql.DB
+	logger     Logger
+	migrations []Migration
+}
+
+// NewMigrator creates a Migrator applying the migrations found in files.
+func NewMigrator(db *sql.DB, files fs.FS, logger Logger) (*Migrator, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[uint]*Migration{}
+	for _, name := range names {
+		match := migrationFile.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("migrator: %s: %w: file name", name, ErrIsNotValid)
+		}
+		version, err := strconv.ParseUint(match[1], 10, 0)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migrator: %s: %w: version", name, ErrIsNotValid)
+		}
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[m.Version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migrator: %s: %w: version %d", name, ErrShouldBeUnique, version)
+		}
+		if match[3] == "up" {
+			m.up = string(data)
+		} else {
+			m.down = string(data)
+		}
+	}
+
+	migrator := &Migrator{db: db, logger: logger}
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migrator: %06d_%s: %w: both up and down files are required", m.Version, m.Name, ErrIsNotValid)
+		}
+		migrator.migrations = append(migrator.migrations, *m)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+	return migrator, nil
+}
+
+// Status returns the state of the database schema.
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := createSchemaMigrations(ctx, conn); err != nil {
+		return nil, err
+	}
+	version, dirty, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationStatus{Version: version, Dirty: dirty, Migrations: m.migrations}, nil
+}
+
+// Up applies the next n pending migrations or, if n is not positive, all of them.
+func (m *Migrator) Up(ctx context.Context, n int) error {
+	return m.migrate(ctx, func(current int) (int, error) {
+		if n <= 0 || current+n >= len(m.migrations) {
+			return len(m.migrations) - 1, nil
+		}
+		return current + n, nil
+	})
+}
+
+// Down reverts the last n applied migrations. n defaults to 1, so that the
+// whole schema is only dropped on purpose, with Goto(ctx, 0).
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	if n <= 0 {
+		n = 1
+	}
+	return m.migrate(ctx, func(current int) (int, error) {
+		if current-n < -1 {
+			return -1, nil
+		}
+		return current - n, nil
+	})
+}
+
+// Goto applies or reverts the migrations until version is the last applied
+// one. Version 0 reverts every migration.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.migrate(ctx, func(int) (int, error) {
+		if version == 0 {
+			return -1, nil
+		}
+		if i, ok := m.index(version); ok {
+			return i, nil
+		}
+		return 0, fmt.Errorf("%w: migration %d", ErrNotFound, version)
+	})
+}
+
+// Force records version as the last applied migration, clearing the dirty
+// flag, without running any migration. It adopts a schema migrated by hand.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	msgError := "migrator.force"
+	if _, ok := m.index(version); !ok && version != 0 {
+		return errors.Wrap(fmt.Errorf("%w: migration %d", ErrNotFound, version), msgError)
+	}
+	if err := createSchemaMigrations(ctx, m.db); err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	if err := setSchemaVersion(ctx, tx, version); err != nil {
+		return errors.Wrap(rollback(tx, err), msgError)
+	}
+	return errors.Wrap(tx.Commit(), msgError)
+}
+
+// index returns the position of the version's migration.
+func (m *Migrator) index(version uint) (int, bool) {
+	i := sort.Search(len(m.migrations), func(i int) bool {
+		return m.migrations[i].Version >= version
+	})
+	return i, i < len(m.migrations) && m.migrations[i].Version == version
+}
+
+// migrate moves the schema to the migration at the position target returns,
+// given the current one. The position -1 stands for no applied migration.
+func (m *Migrator) migrate(ctx context.Context, target func(current int) (int, error)) (err error) {
+	msgError := "migrator.migrate"
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	defer func() {
+		// The lock is released with the session anyway, so a failed unlock
+		// is only worth reporting.
+		if _, e := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID); e != nil && err == nil {
+			err = errors.Wrap(e, msgError)
+		}
+	}()
+
+	if err := createSchemaMigrations(ctx, conn); err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	version, dirty, err := schemaVersion(ctx, conn)
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	if dirty {
+		return errors.Wrap(fmt.Errorf("%w: schema is dirty at version %d and should be fixed by hand", ErrIsNotValid, version), msgError)
+	}
+	current := -1
+	if version != 0 {
+		var ok bool
+		if current, ok = m.index(version); !ok {
+			return errors.Wrap(fmt.Errorf("%w: applied migration %d", ErrNotFound, version), msgError)
+		}
+	}
+	to, err := target(current)
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+
+	for ; current < to; current++ {
+		next := m.migrations[current+1]
+		if err := m.apply(ctx, conn, next, next.up, next.Version); err != nil {
+			return errors.Wrap(err, msgError)
+		}
+		m.logger.Infof("migration %06d_%s applied", next.Version, next.Name)
+	}
+	for ; current > to; current-- {
+		var previous uint
+		if current > 0 {
+			previous = m.migrations[current-1].Version
+		}
+		last := m.migrations[current]
+		if err := m.apply(ctx, conn, last, last.down, previous); err != nil {
+			return errors.Wrap(err, msgError)
+		}
+		m.logger.Infof("migration %06d_%s reverted", last.Version, last.Name)
+	}
+	return nil
+}
+
+// apply runs query, the migration's up or down SQL, and records version as
+// the applied one, in a single transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, query string, version uint) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = rollback(tx, err)
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("%06d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return setSchemaVersion(ctx, tx, version)
+}
+
+func createSchemaMigrations(ctx context.Context, db contextExecer) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`)
+	return err
+}
+
+func schemaVersion(ctx context.Context, db queryRower) (version uint, dirty bool, err error) {
+	err = db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// setSchemaVersion records version, or no version if it is 0. It should run
+// in a transaction.
+func setSchemaVersion(ctx context.Context, db contextExecer, version uint) error {
+	if _, err := db.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	_, err := db.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version)
+	return err
+}
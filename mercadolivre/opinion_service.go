@@ -0,0 +1,67 @@
+package mercadolivre
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type OpinionRequest struct {
+	ProductID string `json:"product_id" validate:"required,uuid,should_exist=products.id"`
+	Rating    int    `validate:"required,min=1,max=5"`
+	Title     string `validate:"required,not_blank"`
+	Desc      string `validate:"required,not_blank,max=500"`
+}
+
+// Validate validates OpinionRequest.
+func (o OpinionRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, o)
+}
+
+// Opinion represents a single Product's Opinion.
+// ID should be globally unique.
+type Opinion struct {
+	ID        string
+	ProductID string `db:"product_id"`
+	UserID    string `db:"user_id"`
+	Rating    int
+	Title     string
+	Desc      string    `db:"description"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// OpinionPost creates Product's opinion.
+func (s *service) OpinionPost(ctx context.Context, opinion OpinionRequest) (string, error) {
+	msgError := "service.opinion_post"
+	userID, err := userIDFrom(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, msgError)
+	}
+
+	id := uuid.New().String()
+	err = s.repo.CreateOpinion(ctx, Opinion{
+		ID:        id,
+		ProductID: opinion.ProductID,
+		UserID:    userID,
+		Rating:    opinion.Rating,
+		Title:     opinion.Title,
+		Desc:      opinion.Desc,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, msgError)
+	}
+	return id, nil
+}
+
+type OpinionResponse struct {
+	ID        string
+	UserName  string `json:"user_name" db:"user_name"`
+	Rating    int
+	Title     string
+	Desc      string    `db:"description"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,119 @@
+package mercadolivre
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const (
+	PurchaseStatusPaid PurchaseStatus = "paid"
+)
+
+type PaymentRequest struct {
+	Gateway       string `validate:"required,oneof=pagseguro paypal"`
+	PurchaseID    string `json:"purchase_id" validate:"required,uuid,should_exist=purchases.id"`
+	TransactionID string `json:"transaction_id" validate:"required,not_blank"`
+	Status        string `validate:"required"`
+
+	// body and signature are the callback as sent, to be authenticated.
+	body      []byte
+	signature string
+}
+
+// Validate validates PaymentRequest.
+func (p PaymentRequest) Validate(ctx context.Context) error {
+	if err := Validate(ctx, p); err != nil {
+		return err
+	}
+	if _, err := Gateway(p.Gateway).TransactionStatus(p.Status); err != nil {
+		return ValidationErrorsResponse{
+			&ValidationErrorResponse{
+				FailedField: "paymentrequest.status",
+				Condition:   err.Error(),
+				ActualValue: p.Status,
+			},
+		}
+	}
+	return nil
+}
+
+type PaymentResponse struct {
+	ID             string
+	PurchaseID     string            `json:"purchase_id"`
+	Status         TransactionStatus `json:"status"`
+	PurchaseStatus PurchaseStatus    `json:"purchase_status"`
+}
+
+// Transaction represents a single payment attempt of a Purchase.
+// ID should be globally unique.
+type Transaction struct {
+	ID                   string
+	PurchaseID           string `db:"purchase_id"`
+	Gateway              Gateway
+	GatewayTransactionID string `db:"gateway_transaction_id"`
+	Status               TransactionStatus
+	Accepted             bool
+	CreatedAt            time.Time `db:"created_at"`
+}
+
+// PaymentPost processes the payment gateway's callback. Every attempt is
+// recorded, but a Purchase is paid only once.
+func (s *service) PaymentPost(ctx context.Context, payment PaymentRequest) (*PaymentResponse, error) {
+	msgError := "service.payment_post"
+	gateway := Gateway(payment.Gateway)
+	status, err := gateway.TransactionStatus(payment.Status)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	purchase, transaction, err := s.repo.RecordTransaction(ctx, Transaction{
+		ID:                   uuid.New().String(),
+		PurchaseID:           payment.PurchaseID,
+		Gateway:              gateway,
+		GatewayTransactionID: payment.TransactionID,
+		Status:               status,
+		CreatedAt:            time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, ErrIsNotValid) && purchase != nil {
+			return nil, ValidationErrorsResponse{
+				&ValidationErrorResponse{
+					FailedField: "paymentrequest.gateway",
+					Condition:   fmt.Sprintf("should be %s", purchase.Gateway),
+					ActualValue: payment.Gateway,
+				},
+			}
+		}
+		return nil, errors.Wrap(err, msgError)
+	}
+	s.metrics.Transactions.With("gateway", string(transaction.Gateway), "status", string(transaction.Status)).Add(1)
+	if status == TransactionStatusSuccess && !transaction.Accepted {
+		return nil, ValidationErrorsResponse{
+			&ValidationErrorResponse{
+				FailedField: "paymentrequest.purchase_id",
+				Condition:   ErrAlreadyPaid.Error(),
+				ActualValue: payment.PurchaseID,
+			},
+		}
+	}
+	if status == TransactionStatusSuccess {
+		s.metrics.Purchases.With("gateway", string(purchase.Gateway), "status", string(purchase.Status)).Add(1)
+		s.firePurchaseConfirmed(ctx, PurchaseConfirmed{
+			PurchaseID: purchase.ID,
+			ProductID:  purchase.ProductID,
+			BuyerID:    purchase.BuyerID,
+			SellerID:   purchase.SellerID,
+		})
+	}
+	return &PaymentResponse{
+		ID:             transaction.ID,
+		PurchaseID:     purchase.ID,
+		Status:         status,
+		PurchaseStatus: purchase.Status,
+	}, nil
+}
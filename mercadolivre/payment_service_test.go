@@ -0,0 +1,81 @@
+package mercadolivre
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+// labelCounter counts by label values, joined with commas.
+type labelCounter struct {
+	mu     *sync.Mutex
+	counts map[string]float64
+	lvs    []string
+}
+
+func newLabelCounter() *labelCounter {
+	return &labelCounter{mu: &sync.Mutex{}, counts: map[string]float64{}}
+}
+
+func (c *labelCounter) With(labelValues ...string) metrics.Counter {
+	return &labelCounter{mu: c.mu, counts: c.counts, lvs: append(append([]string{}, c.lvs...), labelValues...)}
+}
+
+func (c *labelCounter) Add(delta float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[strings.Join(c.lvs, ",")] += delta
+}
+
+func TestPaymentPostCountsTransactions(t *testing.T) {
+	svc, _ := newTestService(t)
+	transactions, purchases := newLabelCounter(), newLabelCounter()
+	svc.metrics.Transactions, svc.metrics.Purchases = transactions, purchases
+
+	owner := contextWithUser(context.Background(), createTestUser(t, svc, "owner@example.com", "secret123"))
+	buyer := contextWithUser(context.Background(), createTestUser(t, svc, "buyer@example.com", "secret123"))
+	categoryID, err := svc.CategoryPost(owner, CategoryRequest{Name: "Books"})
+	if err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+	price, amount := float32(10), int16(1)
+	productID, err := svc.ProductPost(owner, ProductRequest{
+		Name:       "Book",
+		Price:      &price,
+		Amount:     &amount,
+		Features:   []Feature{{Type: "a", Name: "a"}, {Type: "b", Name: "b"}},
+		CategoryID: categoryID,
+	})
+	if err != nil {
+		t.Fatalf("ProductPost: %v", err)
+	}
+	purchase, err := svc.PurchasePost(buyer, PurchaseRequest{ProductID: productID, Quantity: 1, Gateway: "paypal"})
+	if err != nil {
+		t.Fatalf("PurchasePost: %v", err)
+	}
+
+	for _, status := range []string{"0", "1"} {
+		_, err := svc.PaymentPost(context.Background(), PaymentRequest{
+			Gateway:       "paypal",
+			PurchaseID:    purchase.ID,
+			TransactionID: "transaction-" + status,
+			Status:        status,
+		})
+		if err != nil {
+			t.Fatalf("PaymentPost(%s): %v", status, err)
+		}
+	}
+
+	if got := transactions.counts["gateway,paypal,status,failure"]; got != 1 {
+		t.Errorf("failed transactions = %v, want 1", got)
+	}
+	if got := transactions.counts["gateway,paypal,status,success"]; got != 1 {
+		t.Errorf("successful transactions = %v, want 1", got)
+	}
+	if got := purchases.counts["gateway,paypal,status,paid"]; got != 1 {
+		t.Errorf("paid purchases = %v, want 1", got)
+	}
+}
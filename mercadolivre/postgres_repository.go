@@ -0,0 +1,1080 @@
+package mercadolivre
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// layout is the format the timestamps are stored in.
+const layout = "2006-01-02 15:04:05"
+
+type postgresRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresRepository creates a Repository storing into the PostgreSQL db.
+func NewPostgresRepository(db *sqlx.DB) Repository {
+	return &postgresRepository{db: db}
+}
+
+// inTx runs fn in a transaction, which is committed unless fn fails or panics.
+func (r *postgresRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	var tx *sql.Tx
+	tx, err = r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = rollback(tx, err)
+			panic(p)
+		} else if err != nil {
+			err = rollback(tx, err)
+		} else {
+			err = tx.Commit()
+		}
+	}()
+	return fn(tx)
+}
+
+func rollback(tx *sql.Tx, err error) error {
+	if e := tx.Rollback(); e != nil && e != sql.ErrTxDone {
+		if err != nil {
+			return fmt.Errorf("%w: %v", err, e)
+		}
+		return e
+	}
+	return err
+}
+
+// columnConditions are the conditions the rows should also meet to be
+// considered by Exists, by column.
+var columnConditions = map[string]string{
+	"products.id": "deleted_at IS NULL",
+}
+
+func (r *postgresRepository) Columns(ctx context.Context) (map[string]bool, error) {
+	var names []string
+	err := r.db.SelectContext(ctx, &names, `
+		SELECT table_name || '.' || column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+	return columns, nil
+}
+
+func (r *postgresRepository) Exists(ctx context.Context, column, value, exceptID string) (bool, error) {
+	i := strings.Index(column, ".")
+	if i < 0 {
+		return false, fmt.Errorf("%w: column %q", ErrIsNotValid, column)
+	}
+	query := fmt.Sprintf(
+		`SELECT EXISTS (SELECT 1 FROM %s WHERE %s=$1`,
+		pq.QuoteIdentifier(column[:i]),
+		pq.QuoteIdentifier(column[i+1:]),
+	)
+	args := []interface{}{value}
+	if condition, ok := columnConditions[column]; ok {
+		query += " AND " + condition
+	}
+	if exceptID != "" {
+		query += ` AND id<>$2`
+		args = append(args, exceptID)
+	}
+	query += ")"
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&exists)
+	return exists, err
+}
+
+func (r *postgresRepository) CreateUser(ctx context.Context, user User) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO users (id, name, password, created_at) VALUES ($1, $2, $3, $4)",
+		user.ID,
+		user.Name,
+		user.Password,
+		user.CreatedAt.Format(layout),
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: user %s", ErrAlreadyExists, user.Name)
+	}
+	return err
+}
+
+func (r *postgresRepository) User(ctx context.Context, id string) (*User, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: user %s", ErrNotFound, id)
+	}
+	var user User
+	err := r.db.GetContext(ctx, &user, `SELECT id, name, password, created_at FROM users WHERE id=$1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: user %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *postgresRepository) UserByName(ctx context.Context, name string) (*User, error) {
+	var user User
+	err := r.db.GetContext(ctx, &user, `SELECT id, name, password, created_at FROM users WHERE name=$1`, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: user %s", ErrNotFound, name)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *postgresRepository) CreateRefreshToken(ctx context.Context, token RefreshToken) error {
+	return createRefreshToken(ctx, r.db, token)
+}
+
+type contextExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func createRefreshToken(ctx context.Context, db contextExecer, token RefreshToken) error {
+	_, err := db.ExecContext(
+		ctx,
+		"INSERT INTO refresh_tokens (id, family_id, user_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		token.ID,
+		token.FamilyID,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt.Format(layout),
+		token.CreatedAt.Format(layout),
+	)
+	return err
+}
+
+func (r *postgresRepository) RotateRefreshToken(ctx context.Context, tokenHash string, next RefreshToken) (*RefreshToken, error) {
+	var reused bool
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var current RefreshToken
+		var active bool
+		err := tx.QueryRowContext(
+			ctx,
+			"SELECT id, family_id, user_id, revoked_at, expires_at > $2 FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE",
+			tokenHash,
+			next.CreatedAt.Format(layout),
+		).Scan(&current.ID, &current.FamilyID, &current.UserID, &current.RevokedAt, &active)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = fmt.Errorf("%w: refresh token", ErrNotFound)
+			}
+			return err
+		}
+
+		if current.RevokedAt.Valid {
+			// The revocation is committed, so the reuse is reported afterwards.
+			reused = true
+			return revokeRefreshTokenFamily(ctx, tx, current.FamilyID, next.CreatedAt)
+		}
+		if !active {
+			return errRefreshTokenExpired
+		}
+
+		next.FamilyID = current.FamilyID
+		next.UserID = current.UserID
+		if err := createRefreshToken(ctx, tx, next); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			"UPDATE refresh_tokens SET revoked_at = $1, replaced_by = $2 WHERE id = $3",
+			next.CreatedAt.Format(layout),
+			next.ID,
+			current.ID,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, errRefreshTokenReused
+	}
+	return &next, nil
+}
+
+func revokeRefreshTokenFamily(ctx context.Context, db contextExecer, familyID string, now time.Time) error {
+	_, err := db.ExecContext(
+		ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL",
+		now.Format(layout),
+		familyID,
+	)
+	return err
+}
+
+func (r *postgresRepository) RevokeRefreshTokens(ctx context.Context, tokenHash string, all bool) error {
+	var current RefreshToken
+	err := r.db.QueryRowContext(
+		ctx,
+		"SELECT family_id, user_id FROM refresh_tokens WHERE token_hash = $1",
+		tokenHash,
+	).Scan(&current.FamilyID, &current.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: refresh token", ErrNotFound)
+		}
+		return err
+	}
+
+	now := time.Now()
+	if !all {
+		return revokeRefreshTokenFamily(ctx, r.db, current.FamilyID, now)
+	}
+	_, err = r.db.ExecContext(
+		ctx,
+		"UPDATE refresh_tokens SET revoked_at = $1 WHERE user_id = $2 AND revoked_at IS NULL",
+		now.Format(layout),
+		current.UserID,
+	)
+	return err
+}
+
+func (r *postgresRepository) CreateCategory(ctx context.Context, category Category) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO categories (id, name, parent_id) VALUES ($1, $2, $3)",
+		category.ID,
+		category.Name,
+		category.ParentID,
+	)
+	return err
+}
+
+func (r *postgresRepository) CategoryPath(ctx context.Context, id string) ([]Category, error) {
+	var path []Category
+	err := r.db.SelectContext(ctx, &path, `
+		WITH RECURSIVE path (id, name, parent_id, depth, visited) AS (
+			SELECT id, name, parent_id, 0, ARRAY[id]
+			FROM categories
+			WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.parent_id, p.depth + 1, p.visited || c.id
+			FROM categories c
+			JOIN path p ON c.id = p.parent_id
+			WHERE NOT c.id = ANY(p.visited)
+		)
+		SELECT id, name, parent_id FROM path ORDER BY depth DESC`,
+		id,
+	)
+	return path, err
+}
+
+func (r *postgresRepository) CategorySubtree(ctx context.Context, id string) ([]Category, error) {
+	var categories []Category
+	err := r.db.SelectContext(ctx, &categories, `
+		WITH RECURSIVE tree (id, name, parent_id, visited) AS (
+			SELECT id, name, parent_id, ARRAY[id]
+			FROM categories
+			WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.parent_id, t.visited || c.id
+			FROM categories c
+			JOIN tree t ON c.parent_id = t.id
+			WHERE NOT c.id = ANY(t.visited)
+		)
+		SELECT id, name, parent_id FROM tree ORDER BY name`,
+		id,
+	)
+	return categories, err
+}
+
+func (r *postgresRepository) Categories(ctx context.Context) ([]CategoryListResponse, error) {
+	categories := []CategoryListResponse{}
+	err := r.db.SelectContext(ctx, &categories, `
+		SELECT c.id, c.name, COALESCE(c.parent_id::text, '') AS parent_id, COUNT(p.id) AS products_count
+		FROM categories c
+		LEFT JOIN products p ON p.category_id = c.id AND p.deleted_at IS NULL
+		GROUP BY c.id, c.name, c.parent_id
+		ORDER BY c.name`,
+	)
+	return categories, err
+}
+
+// lockCategory locks the Category, returning ErrNotFound if there is none.
+func lockCategory(ctx context.Context, tx *sql.Tx, id string) error {
+	err := tx.QueryRowContext(ctx, "SELECT id FROM categories WHERE id = $1 FOR UPDATE", id).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: category %s", ErrNotFound, id)
+	}
+	return err
+}
+
+func (r *postgresRepository) UpdateCategory(ctx context.Context, update CategoryUpdate) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := lockCategory(ctx, tx, update.ID); err != nil {
+			return err
+		}
+
+		if update.Name != nil {
+			_, err := tx.ExecContext(ctx, "UPDATE categories SET name = $1 WHERE id = $2", *update.Name, update.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if update.ParentID == nil {
+			return nil
+		}
+		parentID := sql.NullString{
+			String: *update.ParentID,
+			Valid:  *update.ParentID != "",
+		}
+		if parentID.Valid {
+			var cycle bool
+			err := tx.QueryRowContext(ctx, `
+				WITH RECURSIVE ancestors (id, parent_id, visited) AS (
+					SELECT id, parent_id, ARRAY[id]
+					FROM categories
+					WHERE id = $1
+					UNION ALL
+					SELECT c.id, c.parent_id, a.visited || c.id
+					FROM categories c
+					JOIN ancestors a ON c.id = a.parent_id
+					WHERE NOT c.id = ANY(a.visited)
+				)
+				SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`,
+				parentID.String,
+				update.ID,
+			).Scan(&cycle)
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return fmt.Errorf("%w: category %s under %s", ErrCreatesCycle, update.ID, parentID.String)
+			}
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE categories SET parent_id = $1 WHERE id = $2", parentID, update.ID)
+		return err
+	})
+}
+
+func (r *postgresRepository) DeleteCategory(ctx context.Context, id, reassignTo string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := lockCategory(ctx, tx, id); err != nil {
+			return err
+		}
+
+		var children int
+		err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM categories WHERE parent_id = $1", id).Scan(&children)
+		if err != nil {
+			return err
+		}
+		if children > 0 {
+			return fmt.Errorf("%w: category %s", ErrHasChildren, id)
+		}
+
+		if reassignTo != "" {
+			_, err = tx.ExecContext(ctx, "UPDATE products SET category_id = $1 WHERE category_id = $2", reassignTo, id)
+			if err != nil {
+				return err
+			}
+		} else {
+			var products int
+			err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL", id).Scan(&products)
+			if err != nil {
+				return err
+			}
+			if products > 0 {
+				return fmt.Errorf("%w: category %s", ErrInUse, id)
+			}
+			_, err = tx.ExecContext(ctx, "UPDATE products SET category_id = NULL WHERE category_id = $1", id)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id)
+		return err
+	})
+}
+
+func (r *postgresRepository) CreateProduct(ctx context.Context, product Product) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO products (id, name, price, amount, description, category_id, owner_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+			product.ID,
+			product.Name,
+			product.Price,
+			product.Amount,
+			product.Desc,
+			product.CategoryID,
+			product.OwnerID,
+			product.CreatedAt.Format(layout),
+		)
+		if err != nil {
+			return err
+		}
+		if err := insertFeatures(ctx, tx, product.ID, product.Features); err != nil {
+			return err
+		}
+		return updateProductSearch(ctx, tx, product.ID)
+	})
+}
+
+// insertFeatures stores the Product's Features.
+func insertFeatures(ctx context.Context, tx *sql.Tx, productID string, features []Feature) error {
+	tStmt, err := tx.PrepareContext(ctx, "INSERT INTO types_of_features (id, product_id, type) VALUES ($1, $2, $3)")
+	if err != nil {
+		return err
+	}
+	fStmt, err := tx.PrepareContext(ctx, "INSERT INTO features (id, type_id, name, details) VALUES ($1, $2, $3, $4)")
+	if err != nil {
+		return err
+	}
+	for _, feature := range features {
+		typeID := uuid.New().String()
+		_, err = tStmt.ExecContext(
+			ctx,
+			typeID,
+			productID,
+			feature.Type)
+		if err != nil {
+			return err
+		}
+
+		_, err = fStmt.ExecContext(
+			ctx,
+			uuid.New().String(),
+			typeID,
+			feature.Name,
+			feature.Details)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceFeatures replaces the Product's Features.
+func replaceFeatures(ctx context.Context, tx *sql.Tx, productID string, features []Feature) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM features WHERE type_id IN (SELECT id FROM types_of_features WHERE product_id = $1)", productID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM types_of_features WHERE product_id = $1", productID)
+	if err != nil {
+		return err
+	}
+	return insertFeatures(ctx, tx, productID, features)
+}
+
+// productRow is a products' row. Its nullable columns are scanned into Product.
+type productRow struct {
+	ID         string
+	Name       string
+	Price      float32
+	Amount     int16
+	Desc       sql.NullString `db:"description"`
+	CategoryID sql.NullString `db:"category_id"`
+	OwnerID    sql.NullString `db:"owner_id"`
+	CreatedAt  sql.NullTime   `db:"created_at"`
+}
+
+func (p productRow) product() Product {
+	return Product{
+		ID:         p.ID,
+		Name:       p.Name,
+		Price:      p.Price,
+		Amount:     p.Amount,
+		Desc:       p.Desc.String,
+		CategoryID: p.CategoryID.String,
+		OwnerID:    p.OwnerID.String,
+		CreatedAt:  p.CreatedAt.Time,
+	}
+}
+
+const productColumns = "id, name, price, amount, description, category_id, owner_id, created_at"
+
+func (r *postgresRepository) Product(ctx context.Context, id string) (*Product, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("%w: product %s", ErrNotFound, id)
+	}
+	var row productRow
+	err := r.db.GetContext(ctx, &row, `SELECT `+productColumns+` FROM products WHERE id=$1 AND deleted_at IS NULL`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("%w: product %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	product := row.product()
+
+	err = r.db.SelectContext(ctx, &product.Features, `
+		SELECT t.type, f.name, f.details
+		FROM types_of_features t
+		JOIN features f ON f.type_id = t.id
+		WHERE t.product_id = $1
+		ORDER BY t.type, f.name`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.SelectContext(ctx, &product.Images, `SELECT url FROM product_images WHERE product_id=$1 ORDER BY created_at, url`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *postgresRepository) Products(ctx context.Context, offset, limit int) ([]Product, int, error) {
+	var total int
+	err := r.db.GetContext(ctx, &total, `SELECT COUNT(*) FROM products WHERE deleted_at IS NULL`)
+	if err != nil {
+		return nil, 0, err
+	}
+	var rows []productRow
+	err = r.db.SelectContext(ctx, &rows, `
+		SELECT `+productColumns+`
+		FROM products
+		WHERE deleted_at IS NULL
+		ORDER BY created_at DESC, id
+		LIMIT $1 OFFSET $2`,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	products := make([]Product, len(rows))
+	for i, row := range rows {
+		products[i] = row.product()
+	}
+	return products, total, nil
+}
+
+// checkProductOwner checks whether ownerID owns the Product, locking it
+// when q is a transaction.
+func checkProductOwner(ctx context.Context, q queryRower, ownerID, productID string, lock bool) error {
+	errNotFound := fmt.Errorf("%w: product %s", ErrNotFound, productID)
+	if _, err := uuid.Parse(productID); err != nil {
+		return errNotFound
+	}
+	query := "SELECT owner_id FROM products WHERE id = $1 AND deleted_at IS NULL"
+	if lock {
+		query += " FOR UPDATE"
+	}
+	var productOwnerID sql.NullString
+	err := q.QueryRowContext(ctx, query, productID).Scan(&productOwnerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errNotFound
+		}
+		return err
+	}
+	if productOwnerID.String != ownerID {
+		return fmt.Errorf("%w: only the owner can change product %s", ErrForbidden, productID)
+	}
+	return nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (r *postgresRepository) CheckProductOwner(ctx context.Context, ownerID, id string) error {
+	return checkProductOwner(ctx, r.db, ownerID, id, false)
+}
+
+func (r *postgresRepository) UpdateProduct(ctx context.Context, update ProductUpdate) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkProductOwner(ctx, tx, update.OwnerID, update.ID, true); err != nil {
+			return err
+		}
+
+		var sets []string
+		var args []interface{}
+		set := func(column string, value interface{}) {
+			args = append(args, value)
+			sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+		if update.Name != nil {
+			set("name", *update.Name)
+		}
+		if update.Price != nil {
+			set("price", *update.Price)
+		}
+		if update.Amount != nil {
+			set("amount", *update.Amount)
+		}
+		if update.Desc != nil {
+			set("description", *update.Desc)
+		}
+		if update.CategoryID != nil {
+			set("category_id", *update.CategoryID)
+		}
+		if len(sets) > 0 {
+			args = append(args, update.ID)
+			query := fmt.Sprintf("UPDATE products SET %s WHERE id = $%d", strings.Join(sets, ", "), len(args))
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+		}
+
+		if update.Features != nil {
+			if err := replaceFeatures(ctx, tx, update.ID, update.Features); err != nil {
+				return err
+			}
+		}
+		return updateProductSearch(ctx, tx, update.ID)
+	})
+}
+
+func (r *postgresRepository) DeleteProduct(ctx context.Context, ownerID, id string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkProductOwner(ctx, tx, ownerID, id, true); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE products SET deleted_at = $1 WHERE id = $2", time.Now().Format(layout), id)
+		return err
+	})
+}
+
+func (r *postgresRepository) AddProductImages(ctx context.Context, ownerID, id string, urls []string) (*Product, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if err := checkProductOwner(ctx, tx, ownerID, id, true); err != nil {
+			return err
+		}
+		stmt, err := tx.PrepareContext(ctx, "INSERT INTO product_images (id, product_id, url, created_at) VALUES ($1, $2, $3, $4)")
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, url := range urls {
+			_, err = stmt.ExecContext(
+				ctx,
+				uuid.New().String(),
+				id,
+				url,
+				now.Format(layout))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Product(ctx, id)
+}
+
+// searchConfig is the text search configuration of the products' search column.
+const searchConfig = "portuguese"
+
+// updateProductSearch refreshes the Product's search column from its
+// name, description and Features.
+func updateProductSearch(ctx context.Context, tx contextExecer, productID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE products p SET search =
+			setweight(to_tsvector('`+searchConfig+`', coalesce(p.name, '')), 'A') ||
+			setweight(to_tsvector('`+searchConfig+`', coalesce(p.description, '')), 'B') ||
+			setweight(to_tsvector('`+searchConfig+`', coalesce((
+				SELECT string_agg(t.type || ' ' || f.name || ' ' || f.details, ' ')
+				FROM types_of_features t
+				JOIN features f ON f.type_id = t.id
+				WHERE t.product_id = p.id
+			), '')), 'C')
+		WHERE p.id = $1`,
+		productID,
+	)
+	return err
+}
+
+// searchSorts maps each sort to the column it orders by, the column's type
+// and the direction. The relevance column is formatted with the text query.
+var searchSorts = map[string]struct {
+	column string
+	typ    string
+	desc   bool
+}{
+	SortNewest:    {column: "COALESCE(p.created_at, 'epoch'::timestamp)", typ: "timestamp", desc: true},
+	SortPriceAsc:  {column: "p.price", typ: "numeric"},
+	SortPriceDesc: {column: "p.price", typ: "numeric", desc: true},
+	SortRelevance: {column: "ts_rank(p.search, %s)", typ: "real", desc: true},
+}
+
+func (r *postgresRepository) SearchProducts(ctx context.Context, search ProductSearch) ([]ProductSearchResult, error) {
+	f := newSearchFilter(search)
+	order := searchSorts[search.Sort]
+	if search.Sort == SortRelevance {
+		order.column = fmt.Sprintf(order.column, f.tsQuery)
+	}
+
+	where, arg := f.conditions(-1), f.arg
+	direction, comparison := "ASC", ">"
+	if order.desc {
+		direction, comparison = "DESC", "<"
+	}
+	if search.After != nil {
+		where = append(where, fmt.Sprintf(
+			"(%s, p.id) %s (%s::%s, %s::uuid)",
+			order.column,
+			comparison,
+			arg(search.After.Value),
+			order.typ,
+			arg(search.After.ID),
+		))
+	}
+
+	query := fmt.Sprintf(`%s
+		SELECT
+			p.id,
+			p.name,
+			p.price,
+			p.amount,
+			COALESCE(p.category_id::text, '') AS category_id,
+			p.created_at,
+			COALESCE(r.average_rating, 0) AS average_rating,
+			(%s)::text AS cursor_value
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, AVG(rating) AS average_rating
+			FROM opinions
+			GROUP BY product_id
+		) r ON r.product_id = p.id
+		WHERE %s
+		ORDER BY %s %s, p.id %s
+		LIMIT %s`,
+		f.with,
+		order.column,
+		strings.Join(where, " AND "),
+		order.column, direction, direction,
+		arg(search.Limit),
+	)
+
+	products := []ProductSearchResult{}
+	if err := r.db.SelectContext(ctx, &products, query, f.args...); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// searchFilter holds the SQL conditions shared by the search's queries.
+type searchFilter struct {
+	with  string
+	where []string
+	// facets are the conditions on the Features, by type.
+	facets  []facetFilter
+	args    []interface{}
+	tsQuery string
+}
+
+// facetFilter is the condition on the Features of a type.
+type facetFilter struct {
+	typ       string
+	condition string
+}
+
+func newSearchFilter(req ProductSearch) *searchFilter {
+	f := &searchFilter{
+		where: []string{"p.deleted_at IS NULL"},
+	}
+	if req.Query != "" {
+		f.tsQuery = fmt.Sprintf("websearch_to_tsquery('%s', %s)", searchConfig, f.arg(req.Query))
+		f.add("p.search @@ " + f.tsQuery)
+	}
+	if req.CategoryID != "" {
+		f.with = `WITH RECURSIVE tree (id, visited) AS (
+			SELECT id, ARRAY[id] FROM categories WHERE id = ` + f.arg(req.CategoryID) + `
+			UNION ALL
+			SELECT c.id, t.visited || c.id
+			FROM categories c
+			JOIN tree t ON c.parent_id = t.id
+			WHERE NOT c.id = ANY(t.visited)
+		)`
+		f.add("p.category_id IN (SELECT id FROM tree)")
+	}
+	if req.MinPrice != nil {
+		f.add("p.price >= " + f.arg(*req.MinPrice))
+	}
+	if req.MaxPrice != nil {
+		f.add("p.price <= " + f.arg(*req.MaxPrice))
+	}
+	if req.MinRating != nil {
+		f.add("COALESCE(r.average_rating, 0) >= " + f.arg(*req.MinRating))
+	}
+	if req.InStock {
+		f.add("p.amount > 0")
+	}
+
+	types := make([]string, 0, len(req.Facets))
+	for typ := range req.Facets {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		f.facets = append(f.facets, facetFilter{
+			typ: typ,
+			condition: fmt.Sprintf(`EXISTS (
+				SELECT 1
+				FROM types_of_features t
+				JOIN features f ON f.type_id = t.id
+				WHERE t.product_id = p.id AND t.type = %s AND f.name = ANY(%s)
+			)`, f.arg(typ), f.arg(pq.Array(req.Facets[typ]))),
+		})
+	}
+	return f
+}
+
+// arg adds v to the arguments and returns its placeholder.
+func (f *searchFilter) arg(v interface{}) string {
+	f.args = append(f.args, v)
+	return fmt.Sprintf("$%d", len(f.args))
+}
+
+// add adds the condition.
+func (f *searchFilter) add(condition string) {
+	f.where = append(f.where, condition)
+}
+
+// conditions returns the conditions but the except-th facet's; -1 keeps
+// every facet's.
+func (f *searchFilter) conditions(except int) []string {
+	conditions := append([]string(nil), f.where...)
+	for i, facet := range f.facets {
+		if i != except {
+			conditions = append(conditions, facet.condition)
+		}
+	}
+	return conditions
+}
+
+func (r *postgresRepository) SearchFacets(ctx context.Context, search ProductSearch) ([]Facet, error) {
+	f := newSearchFilter(search)
+	var rows []struct {
+		Type  string
+		Name  string
+		Count int
+	}
+	facetSelect := func(where []string) string {
+		return fmt.Sprintf(`
+		SELECT t.type, fe.name, COUNT(DISTINCT p.id) AS count
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, AVG(rating) AS average_rating
+			FROM opinions
+			GROUP BY product_id
+		) r ON r.product_id = p.id
+		JOIN types_of_features t ON t.product_id = p.id
+		JOIN features fe ON fe.type_id = t.id
+		WHERE %s
+		GROUP BY t.type, fe.name`,
+			strings.Join(where, " AND "),
+		)
+	}
+
+	where := f.conditions(-1)
+	if len(f.facets) > 0 {
+		types := make([]string, len(f.facets))
+		for i, facet := range f.facets {
+			types[i] = facet.typ
+		}
+		where = append(where, "t.type <> ALL("+f.arg(pq.Array(types))+")")
+	}
+	selects := []string{facetSelect(where)}
+	for i, facet := range f.facets {
+		where := append(f.conditions(i), "t.type = "+f.arg(facet.typ))
+		selects = append(selects, facetSelect(where))
+	}
+	query := fmt.Sprintf(`%s%s
+		ORDER BY type, count DESC, name`,
+		f.with,
+		strings.Join(selects, "\n\t\tUNION ALL"),
+	)
+	if err := r.db.SelectContext(ctx, &rows, query, f.args...); err != nil {
+		return nil, err
+	}
+
+	facets := []Facet{}
+	for _, row := range rows {
+		if len(facets) == 0 || facets[len(facets)-1].Type != row.Type {
+			facets = append(facets, Facet{Type: row.Type})
+		}
+		facet := &facets[len(facets)-1]
+		facet.Values = append(facet.Values, FacetValue{
+			Name:  row.Name,
+			Count: row.Count,
+		})
+	}
+	return facets, nil
+}
+
+func (r *postgresRepository) CreatePurchase(ctx context.Context, purchase Purchase) (price float32, err error) {
+	err = r.inTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(
+			ctx,
+			"UPDATE products SET amount = amount - $1 WHERE id = $2 AND amount >= $1 AND deleted_at IS NULL RETURNING price",
+			purchase.Quantity,
+			purchase.ProductID,
+		).Scan(&price)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = fmt.Errorf("%w: product %s", ErrInsufficientStock, purchase.ProductID)
+			}
+			return err
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO purchases (id, product_id, buyer_id, quantity, price, gateway, status, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+			purchase.ID,
+			purchase.ProductID,
+			purchase.BuyerID,
+			purchase.Quantity,
+			price,
+			purchase.Gateway,
+			purchase.Status,
+			purchase.CreatedAt.Format(layout))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return price, nil
+}
+
+func (r *postgresRepository) RecordTransaction(ctx context.Context, transaction Transaction) (*Purchase, *Transaction, error) {
+	var purchase Purchase
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var sellerID sql.NullString
+		err := tx.QueryRowContext(ctx, `
+			SELECT pu.id, pu.product_id, pu.buyer_id, pu.gateway, pu.status, pr.owner_id
+			FROM purchases pu
+			JOIN products pr ON pr.id = pu.product_id
+			WHERE pu.id = $1
+			FOR UPDATE OF pu`,
+			transaction.PurchaseID,
+		).Scan(&purchase.ID, &purchase.ProductID, &purchase.BuyerID, &purchase.Gateway, &purchase.Status, &sellerID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				err = fmt.Errorf("%w: purchase %s", ErrNotFound, transaction.PurchaseID)
+			}
+			return err
+		}
+		purchase.SellerID = sellerID.String
+		if purchase.Gateway != transaction.Gateway {
+			return fmt.Errorf("%w: purchase %s was started at %s", ErrIsNotValid, purchase.ID, purchase.Gateway)
+		}
+
+		transaction.Accepted = purchase.Status != PurchaseStatusPaid
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO transactions (id, purchase_id, gateway, gateway_transaction_id, status, accepted, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			transaction.ID,
+			transaction.PurchaseID,
+			transaction.Gateway,
+			transaction.GatewayTransactionID,
+			transaction.Status,
+			transaction.Accepted,
+			transaction.CreatedAt.Format(layout))
+		if err != nil {
+			return err
+		}
+
+		if transaction.Accepted && transaction.Status == TransactionStatusSuccess {
+			_, err = tx.ExecContext(ctx, "UPDATE purchases SET status = $1 WHERE id = $2", PurchaseStatusPaid, purchase.ID)
+			if err != nil {
+				return err
+			}
+			purchase.Status = PurchaseStatusPaid
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrIsNotValid) {
+			return &purchase, nil, err
+		}
+		return nil, nil, err
+	}
+	return &purchase, &transaction, nil
+}
+
+func (r *postgresRepository) CreateOpinion(ctx context.Context, opinion Opinion) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO opinions (id, product_id, user_id, rating, title, description, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		opinion.ID,
+		opinion.ProductID,
+		opinion.UserID,
+		opinion.Rating,
+		opinion.Title,
+		opinion.Desc,
+		opinion.CreatedAt.Format(layout),
+	)
+	return err
+}
+
+func (r *postgresRepository) ProductOpinions(ctx context.Context, productID string) ([]OpinionResponse, error) {
+	var opinions []OpinionResponse
+	err := r.db.SelectContext(ctx, &opinions, `
+		SELECT o.id, u.name AS user_name, o.rating, o.title, o.description, o.created_at
+		FROM opinions o
+		JOIN users u ON u.id = o.user_id
+		WHERE o.product_id = $1
+		ORDER BY o.created_at DESC`,
+		productID,
+	)
+	return opinions, err
+}
+
+func (r *postgresRepository) ProductRating(ctx context.Context, productID string) (average float64, count int, err error) {
+	err = r.db.QueryRowContext(ctx, `SELECT COALESCE(AVG(rating), 0), COUNT(*) FROM opinions WHERE product_id=$1`, productID).
+		Scan(&average, &count)
+	return average, count, err
+}
+
+func (r *postgresRepository) CreateQuestion(ctx context.Context, question Question) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO questions (id, product_id, user_id, title, created_at) VALUES ($1, $2, $3, $4, $5)",
+		question.ID,
+		question.ProductID,
+		question.UserID,
+		question.Title,
+		question.CreatedAt.Format(layout),
+	)
+	return err
+}
+
+func (r *postgresRepository) ProductQuestions(ctx context.Context, productID string) ([]QuestionResponse, error) {
+	var questions []QuestionResponse
+	err := r.db.SelectContext(ctx, &questions, `
+		SELECT q.id, u.name AS user_name, q.title, q.created_at
+		FROM questions q
+		JOIN users u ON u.id = q.user_id
+		WHERE q.product_id = $1
+		ORDER BY q.created_at`,
+		productID,
+	)
+	return questions, err
+}
@@ -0,0 +1,88 @@
+package mercadolivre
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type ProductImagesRequest struct {
+	ProductID string  `json:"product_id" validate:"required,uuid,should_exist=products.id"`
+	Images    []Image `validate:"required,min=1,dive"`
+}
+
+// Validate validates ProductImagesRequest.
+func (p ProductImagesRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, p)
+}
+
+// imageExtensions are the stored images' extensions by content type. The
+// extensions never come from the uploaded files' names, so that the images
+// are always served as images.
+var imageExtensions = map[string]string{
+	"image/gif":  ".gif",
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// Image represents a single uploaded image.
+// Its ContentType is sniffed from its Content.
+type Image struct {
+	Name        string `validate:"required,not_blank"`
+	ContentType string `json:"content_type" validate:"required,oneof=image/gif image/jpeg image/png image/webp"`
+	Content     []byte `validate:"required"`
+}
+
+// ProductImagesPost stores the Product's images.
+func (s *service) ProductImagesPost(ctx context.Context, req ProductImagesRequest) (res *ProductResponse, err error) {
+	msgError := "service.product_images_post"
+	userID, err := userIDFrom(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	var keys []string
+	defer func() {
+		if err != nil {
+			for _, key := range keys {
+				if e := s.storage.Delete(ctx, key); e != nil {
+					s.logger.Errorf("%s: could not delete %s: %v", msgError, key, e)
+				}
+			}
+		}
+	}()
+
+	err = s.repo.CheckProductOwner(ctx, userID, req.ProductID)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	var urls []string
+	for _, image := range req.Images {
+		ext, ok := imageExtensions[image.ContentType]
+		if !ok {
+			err = fmt.Errorf("%w: content type %s", ErrIsNotValid, image.ContentType)
+			return nil, errors.Wrap(err, msgError)
+		}
+		key := fmt.Sprintf("products/%s/%s%s", req.ProductID, uuid.New().String(), ext)
+		var url string
+		url, err = s.storage.Put(ctx, key, image.ContentType, bytes.NewReader(image.Content))
+		if err != nil {
+			return nil, errors.Wrap(err, msgError)
+		}
+		keys = append(keys, key)
+		urls = append(urls, url)
+	}
+
+	product, err := s.repo.AddProductImages(ctx, userID, req.ProductID, urls)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+	response := productResponse(*product)
+	return &response, nil
+}
@@ -3,7 +3,6 @@ package mercadolivre
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,18 +16,23 @@ type ProductRequest struct {
 	Amount     *int16    `validate:"required,gte=0"`
 	Features   []Feature `validate:"required,min=2"`
 	Desc       string    `validate:"required,max=100"`
-	CategoryID string    `json:"category_id" validate:"required,not_blank,should_exist"`
+	CategoryID string    `json:"category_id" validate:"required,uuid,should_exist=categories.id"`
 	CreatedAt  time.Time
 }
 
 // Validate validates ProductRequest.
-func (u ProductRequest) Validate() error {
-	return Validate(u)
+func (u ProductRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, u)
 }
 
 type ProductResponse struct {
-	ID   string
-	Name string
+	ID         string
+	Name       string
+	Price      float32
+	Amount     int16
+	CategoryID string    `json:"category_id" db:"category_id"`
+	Images     []string  `json:",omitempty"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 // Product represents a single Product.
@@ -40,9 +44,13 @@ type Product struct {
 	Amount     int16
 	Features   []Feature
 	Desc       string
+	Images     []string
 	CategoryID string
 	Category   Category
-	CreatedAt  time.Time `db:"created_at"`
+	OwnerID    string
+	Owner      User
+	CreatedAt  time.Time    `db:"created_at"`
+	DeletedAt  sql.NullTime `db:"deleted_at"`
 }
 
 // Featrue represents a single Product's Feature.
@@ -53,85 +61,266 @@ type Feature struct {
 }
 
 // ProductPost creates Product.
-func (s *service) ProductPost(ctx context.Context, product ProductRequest) (productID string, err error) {
+func (s *service) ProductPost(ctx context.Context, product ProductRequest) (string, error) {
 	msgError := "service.product_post"
-	var tx *sql.Tx
-	tx, err = s.db.Begin()
+	ownerID, err := userIDFrom(ctx)
 	if err != nil {
 		return "", errors.Wrap(err, msgError)
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			productID = ""
-			err = rollback(tx, err)
-			panic(p)
-		} else if err != nil {
-			productID = ""
-			err = rollback(tx, err)
-		} else {
-			if err := tx.Commit(); err != nil {
-				productID = ""
-				err = errors.Wrap(err, msgError)
-			}
-		}
-	}()
 
-	pStmt, err := tx.Prepare("INSERT INTO products (id, name, price, amount, description, category_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)")
+	productID := uuid.New().String()
+	err = s.repo.CreateProduct(ctx, Product{
+		ID:         productID,
+		Name:       product.Name,
+		Price:      *product.Price,
+		Amount:     *product.Amount,
+		Features:   product.Features,
+		Desc:       product.Desc,
+		CategoryID: product.CategoryID,
+		OwnerID:    ownerID,
+		CreatedAt:  time.Now(),
+	})
 	if err != nil {
 		return "", errors.Wrap(err, msgError)
 	}
-	productID = uuid.New().String()
-	now := time.Now()
-	layout := "2006-01-02 15:04:05"
-	_, err = pStmt.Exec(
-		productID,
-		product.Name,
-		product.Price,
-		product.Amount,
-		product.Desc,
-		product.CategoryID,
-		now.Format(layout))
-	if err != nil {
-		return "", errors.Wrap(err, msgError)
+	s.metrics.ProductsCreated.Add(1)
+	return productID, nil
+}
+
+// productResponse returns the ProductResponse of product.
+func productResponse(product Product) ProductResponse {
+	return ProductResponse{
+		ID:         product.ID,
+		Name:       product.Name,
+		Price:      product.Price,
+		Amount:     product.Amount,
+		CategoryID: product.CategoryID,
+		Images:     product.Images,
+		CreatedAt:  product.CreatedAt,
 	}
+}
 
-	tStmt, err := tx.Prepare("INSERT INTO types_of_features (id, product_id, type) VALUES ($1, $2, $3)")
+type ProductDetailResponse struct {
+	ID            string
+	Name          string
+	Price         float32
+	Amount        int16
+	Desc          string
+	CategoryPath  []CategoryResponse `json:"category_path"`
+	Features      []FeatureGroup
+	Images        []string
+	AverageRating float64 `json:"average_rating"`
+	OpinionsCount int     `json:"opinions_count"`
+	Opinions      []OpinionResponse
+	Questions     []QuestionResponse
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// FeatureGroup represents the Product's Features of the same type.
+type FeatureGroup struct {
+	Type   string
+	Values []FeatureValue
+}
+
+// FeatureValue represents a single Feature's value.
+type FeatureValue struct {
+	Name    string
+	Details string
+}
+
+// ProductGet returns the Product's details.
+func (s *service) ProductGet(ctx context.Context, productID string) (*ProductDetailResponse, error) {
+	msgError := "service.product_get"
+	product, err := s.repo.Product(ctx, productID)
 	if err != nil {
-		return "", errors.Wrap(err, msgError)
+		return nil, errors.Wrap(err, msgError)
 	}
-	fStmt, err := tx.Prepare("INSERT INTO features (id, type_id, name, details) VALUES ($1, $2, $3, $4)")
-	if err != nil {
-		return "", errors.Wrap(err, msgError)
+	res := &ProductDetailResponse{
+		ID:        product.ID,
+		Name:      product.Name,
+		Price:     product.Price,
+		Amount:    product.Amount,
+		Desc:      product.Desc,
+		Features:  groupFeatures(product.Features),
+		Images:    product.Images,
+		CreatedAt: product.CreatedAt,
 	}
-	for _, feature := range product.Features {
-		typeID := uuid.New().String()
-		_, err = tStmt.Exec(
-			typeID,
-			productID,
-			feature.Type)
+
+	if product.CategoryID != "" {
+		res.CategoryPath, err = s.categoryPath(ctx, product.CategoryID)
 		if err != nil {
-			return "", errors.Wrap(err, msgError)
+			return nil, errors.Wrap(err, msgError)
 		}
+	}
 
-		_, err = fStmt.Exec(
-			uuid.New().String(),
-			typeID,
-			feature.Name,
-			feature.Details)
-		if err != nil {
-			return "", errors.Wrap(err, msgError)
+	res.AverageRating, res.OpinionsCount, err = s.repo.ProductRating(ctx, productID)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	res.Opinions, err = s.repo.ProductOpinions(ctx, productID)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	res.Questions, err = s.repo.ProductQuestions(ctx, productID)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	return res, nil
+}
+
+// groupFeatures groups the Features by type. They should be sorted by type.
+func groupFeatures(features []Feature) []FeatureGroup {
+	var groups []FeatureGroup
+	for _, feature := range features {
+		if len(groups) == 0 || groups[len(groups)-1].Type != feature.Type {
+			groups = append(groups, FeatureGroup{Type: feature.Type})
 		}
+		group := &groups[len(groups)-1]
+		group.Values = append(group.Values, FeatureValue{
+			Name:    feature.Name,
+			Details: feature.Details,
+		})
 	}
+	return groups
+}
 
-	return
+type ProductsRequest struct {
+	Page    int `validate:"gte=1"`
+	PerPage int `json:"per_page" validate:"gte=1,lte=100"`
 }
 
-func rollback(tx *sql.Tx, err error) error {
-	if e := tx.Rollback(); e != nil && e != sql.ErrTxDone {
-		if err != nil {
-			return fmt.Errorf("%w: %v", err, e)
+// Validate validates ProductsRequest.
+func (p ProductsRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, p)
+}
+
+type ProductsResponse struct {
+	Products []ProductResponse
+	Page     int
+	PerPage  int `json:"per_page"`
+	Total    int
+}
+
+// ProductsGet lists the Products, newest first.
+func (s *service) ProductsGet(ctx context.Context, req ProductsRequest) (*ProductsResponse, error) {
+	msgError := "service.products_get"
+	res := &ProductsResponse{
+		Products: []ProductResponse{},
+		Page:     req.Page,
+		PerPage:  req.PerPage,
+	}
+	products, total, err := s.repo.Products(ctx, (req.Page-1)*req.PerPage, req.PerPage)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+	res.Total = total
+	for _, product := range products {
+		res.Products = append(res.Products, productResponse(product))
+	}
+	return res, nil
+}
+
+type ProductPutRequest struct {
+	ID string `json:"-" validate:"required,uuid"`
+	ProductRequest
+}
+
+// Validate validates ProductPutRequest.
+func (p ProductPutRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, p)
+}
+
+// ProductPut replaces the Product. Only the Product's owner can replace it.
+func (s *service) ProductPut(ctx context.Context, product ProductPutRequest) error {
+	msgError := "service.product_put"
+	userID, err := userIDFrom(ctx)
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+
+	err = s.repo.UpdateProduct(ctx, ProductUpdate{
+		ID:         product.ID,
+		OwnerID:    userID,
+		Name:       &product.Name,
+		Price:      product.Price,
+		Amount:     product.Amount,
+		Features:   product.Features,
+		Desc:       &product.Desc,
+		CategoryID: &product.CategoryID,
+	})
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	return nil
+}
+
+type ProductPatchRequest struct {
+	ID         string    `json:"-" validate:"required,uuid"`
+	Name       *string   `validate:"omitempty,not_blank"`
+	Price      *float32  `validate:"omitempty,gt=0"`
+	Amount     *int16    `validate:"omitempty,gte=0"`
+	Features   []Feature `validate:"omitempty,min=2"`
+	Desc       *string   `validate:"omitempty,max=100"`
+	CategoryID *string   `json:"category_id" validate:"omitempty,uuid,should_exist=categories.id"`
+}
+
+// Validate validates ProductPatchRequest.
+func (p ProductPatchRequest) Validate(ctx context.Context) error {
+	if err := Validate(ctx, p); err != nil {
+		return err
+	}
+	// omitempty skips an empty Features too, which would delete them all:
+	// only a missing Features keeps them.
+	if p.Features != nil && len(p.Features) == 0 {
+		return ValidationErrorsResponse{
+			&ValidationErrorResponse{
+				FailedField: "productpatchrequest.features",
+				Condition:   "should have at least 2 features",
+			},
 		}
-		return e
 	}
-	return err
+	return nil
+}
+
+// ProductPatch updates the given fields of the Product. Only the Product's
+// owner can update it.
+func (s *service) ProductPatch(ctx context.Context, product ProductPatchRequest) error {
+	msgError := "service.product_patch"
+	userID, err := userIDFrom(ctx)
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+
+	err = s.repo.UpdateProduct(ctx, ProductUpdate{
+		ID:         product.ID,
+		OwnerID:    userID,
+		Name:       product.Name,
+		Price:      product.Price,
+		Amount:     product.Amount,
+		Features:   product.Features,
+		Desc:       product.Desc,
+		CategoryID: product.CategoryID,
+	})
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	return nil
+}
+
+// ProductDelete soft deletes the Product, so the Purchases referencing it
+// stay valid. Only the Product's owner can delete it.
+func (s *service) ProductDelete(ctx context.Context, productID string) error {
+	msgError := "service.product_delete"
+	userID, err := userIDFrom(ctx)
+	if err != nil {
+		return errors.Wrap(err, msgError)
+	}
+
+	if err := s.repo.DeleteProduct(ctx, userID, productID); err != nil {
+		return errors.Wrap(err, msgError)
+	}
+	return nil
 }
@@ -0,0 +1,153 @@
+package mercadolivre
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestProductPost(t *testing.T) {
+	svc, repo := newTestService(t)
+	ownerID := createTestUser(t, svc, "owner@example.com", "secret123")
+	ctx := contextWithUser(context.Background(), ownerID)
+	categoryID, err := svc.CategoryPost(ctx, CategoryRequest{Name: "Books"})
+	if err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+
+	price, amount := float32(10.5), int16(3)
+	req := ProductRequest{
+		Name:   "Book",
+		Price:  &price,
+		Amount: &amount,
+		Features: []Feature{
+			{Type: "cover", Name: "hard", Details: "hardcover"},
+			{Type: "pages", Name: "300", Details: "300 pages"},
+		},
+		Desc:       "A book",
+		CategoryID: categoryID,
+	}
+	if err := req.Validate(ctx); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	id, err := svc.ProductPost(ctx, req)
+	if err != nil {
+		t.Fatalf("ProductPost: %v", err)
+	}
+
+	product, err := repo.Product(ctx, id)
+	if err != nil {
+		t.Fatalf("Product: %v", err)
+	}
+	if product.Name != req.Name || product.Price != price || product.Amount != amount || product.CategoryID != categoryID {
+		t.Errorf("product = %+v, want the request's fields", product)
+	}
+	if product.OwnerID != ownerID {
+		t.Errorf("OwnerID = %q, want %q", product.OwnerID, ownerID)
+	}
+	if len(product.Features) != 2 {
+		t.Errorf("Features = %v, want 2 features", product.Features)
+	}
+}
+
+func TestProductGet(t *testing.T) {
+	svc, _ := newTestService(t)
+	ownerID := createTestUser(t, svc, "owner@example.com", "secret123")
+	buyerID := createTestUser(t, svc, "buyer@example.com", "secret123")
+	price, amount := float32(10), int16(1)
+	productID, err := svc.ProductPost(contextWithUser(context.Background(), ownerID), ProductRequest{
+		Name:     "Book",
+		Price:    &price,
+		Amount:   &amount,
+		Features: []Feature{{Type: "a", Name: "a"}, {Type: "b", Name: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("ProductPost: %v", err)
+	}
+	ctx := contextWithUser(context.Background(), buyerID)
+	for _, rating := range []int{4, 5} {
+		if _, err := svc.OpinionPost(ctx, OpinionRequest{ProductID: productID, Rating: rating, Title: "t", Desc: "d"}); err != nil {
+			t.Fatalf("OpinionPost: %v", err)
+		}
+	}
+	if _, err := svc.QuestionPost(ctx, QuestionRequest{ProductID: productID, Title: "Is it new?"}); err != nil {
+		t.Fatalf("QuestionPost: %v", err)
+	}
+
+	res, err := svc.ProductGet(context.Background(), productID)
+	if err != nil {
+		t.Fatalf("ProductGet: %v", err)
+	}
+	if res.AverageRating != 4.5 || res.OpinionsCount != 2 || len(res.Opinions) != 2 {
+		t.Errorf("AverageRating = %v, OpinionsCount = %d, Opinions = %v, want 4.5, 2 and 2 opinions",
+			res.AverageRating, res.OpinionsCount, res.Opinions)
+	}
+	if len(res.Questions) != 1 || res.Questions[0].UserName != "buyer@example.com" {
+		t.Errorf("Questions = %v, want the buyer's question", res.Questions)
+	}
+}
+
+func TestProductPostUnauthenticated(t *testing.T) {
+	svc, _ := newTestService(t)
+	price, amount := float32(1), int16(1)
+	_, err := svc.ProductPost(context.Background(), ProductRequest{Name: "Book", Price: &price, Amount: &amount})
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("ProductPost error = %v, want %v", err, ErrAuthFailed)
+	}
+}
+
+func TestProductRequestValidate(t *testing.T) {
+	// The service registers the should_exist validation.
+	newTestService(t)
+	ctx := contextWithUser(context.Background(), uuid.New().String())
+	price, amount := float32(10), int16(1)
+	features := []Feature{{Type: "a", Name: "a"}, {Type: "b", Name: "b"}}
+
+	tests := []struct {
+		name  string
+		req   ProductRequest
+		field string
+	}{
+		{"one feature", ProductRequest{Name: "Book", Price: &price, Amount: &amount, Features: features[:1], Desc: "d", CategoryID: uuid.New().String()}, "productrequest.features"},
+		{"unknown category", ProductRequest{Name: "Book", Price: &price, Amount: &amount, Features: features, Desc: "d", CategoryID: uuid.New().String()}, "productrequest.category_id"},
+		{"blank name", ProductRequest{Name: " ", Price: &price, Amount: &amount, Features: features, Desc: "d", CategoryID: uuid.New().String()}, "productrequest.name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate(ctx)
+			if !hasFailedField(err, tt.field) {
+				t.Fatalf("Validate error = %v, want a failure of %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestProductPatchRequestValidateFeatures(t *testing.T) {
+	ctx := context.Background()
+	id := uuid.New().String()
+	features := []Feature{{Type: "a", Name: "a"}, {Type: "b", Name: "b"}}
+
+	tests := []struct {
+		name     string
+		features []Feature
+		valid    bool
+	}{
+		{"missing", nil, true},
+		{"empty", []Feature{}, false},
+		{"one", features[:1], false},
+		{"two", features, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ProductPatchRequest{ID: id, Features: tt.features}.Validate(ctx)
+			if tt.valid && err != nil {
+				t.Fatalf("Validate error = %v, want nil", err)
+			}
+			if !tt.valid && !hasFailedField(err, "productpatchrequest.features") {
+				t.Fatalf("Validate error = %v, want a failure of productpatchrequest.features", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package mercadolivre
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+type invoiceHandler struct {
+	url    string
+	client *http.Client
+}
+
+// NewInvoiceHandler creates a PurchaseConfirmedHandler that sends the
+// purchase and the buyer to the invoice system at url.
+func NewInvoiceHandler(url string, client *http.Client) PurchaseConfirmedHandler {
+	return &invoiceHandler{
+		url:    url,
+		client: client,
+	}
+}
+
+// HandlePurchaseConfirmed asks the invoice system to issue the purchase's invoice.
+func (i *invoiceHandler) HandlePurchaseConfirmed(ctx context.Context, event PurchaseConfirmed) error {
+	return postJSON(ctx, i.client, i.url, InvoiceRequest{
+		PurchaseID: event.PurchaseID,
+		BuyerID:    event.BuyerID,
+	})
+}
+
+type rankingHandler struct {
+	url    string
+	client *http.Client
+}
+
+// NewRankingHandler creates a PurchaseConfirmedHandler that sends the
+// purchase and the seller to the seller-ranking system at url.
+func NewRankingHandler(url string, client *http.Client) PurchaseConfirmedHandler {
+	return &rankingHandler{
+		url:    url,
+		client: client,
+	}
+}
+
+// HandlePurchaseConfirmed tells the seller-ranking system about the sale.
+func (r *rankingHandler) HandlePurchaseConfirmed(ctx context.Context, event PurchaseConfirmed) error {
+	return postJSON(ctx, r.client, r.url, RankingRequest{
+		PurchaseID: event.PurchaseID,
+		SellerID:   event.SellerID,
+	})
+}
+
+// InvoiceRequest is the body sent to the invoice system.
+type InvoiceRequest struct {
+	PurchaseID string `json:"purchase_id"`
+	BuyerID    string `json:"buyer_id"`
+}
+
+// RankingRequest is the body sent to the seller-ranking system.
+type RankingRequest struct {
+	PurchaseID string `json:"purchase_id"`
+	SellerID   string `json:"seller_id"`
+}
+
+// postJSON posts body to url. The responses which retrying would not change,
+// the other client errors than 429, fail with ErrPermanent.
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		err := fmt.Errorf("POST %s: %s", url, res.Status)
+		// Only the server errors and the rate limiting may pass by retrying.
+		if res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+			return fmt.Errorf("%w: %v", ErrPermanent, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// NewInvoiceStandIn creates an http.Handler that stands in for the invoice
+// system, logging every InvoiceRequest it receives.
+func NewInvoiceStandIn(logger Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req InvoiceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PurchaseID == "" || req.BuyerID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		logger.Infow("invoice issued", "purchase_id", req.PurchaseID, "buyer_id", req.BuyerID)
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// NewRankingStandIn creates an http.Handler that stands in for the
+// seller-ranking system, logging every RankingRequest it receives.
+func NewRankingStandIn(logger Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req RankingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PurchaseID == "" || req.SellerID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		logger.Infow("seller ranked", "purchase_id", req.PurchaseID, "seller_id", req.SellerID)
+		w.WriteHeader(http.StatusOK)
+	})
+}
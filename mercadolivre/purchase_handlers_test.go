@@ -0,0 +1,67 @@
+package mercadolivre
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPurchaseConfirmedHandlersWithStandIns(t *testing.T) {
+	logger := NewLogger(ErrorLevel)
+	tests := []struct {
+		name    string
+		standIn http.Handler
+		handler func(url string) PurchaseConfirmedHandler
+	}{
+		{"invoice", NewInvoiceStandIn(logger), func(url string) PurchaseConfirmedHandler {
+			return NewInvoiceHandler(url, http.DefaultClient)
+		}},
+		{"ranking", NewRankingStandIn(logger), func(url string) PurchaseConfirmedHandler {
+			return NewRankingHandler(url, http.DefaultClient)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.standIn)
+			defer server.Close()
+			handler := tt.handler(server.URL)
+
+			event := PurchaseConfirmed{PurchaseID: "purchase", ProductID: "product", BuyerID: "buyer", SellerID: "seller"}
+			if err := handler.HandlePurchaseConfirmed(context.Background(), event); err != nil {
+				t.Fatalf("HandlePurchaseConfirmed: %v", err)
+			}
+			if err := handler.HandlePurchaseConfirmed(context.Background(), PurchaseConfirmed{}); !errors.Is(err, ErrPermanent) {
+				t.Fatalf("HandlePurchaseConfirmed of an empty event error = %v, want the stand-in's 400 as %v", err, ErrPermanent)
+			}
+		})
+	}
+}
+
+func TestPostJSONRetryableStatuses(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusConflict, true},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := postJSON(context.Background(), http.DefaultClient, server.URL, struct{}{})
+			if err == nil || errors.Is(err, ErrPermanent) != tt.permanent {
+				t.Fatalf("postJSON error = %v, want permanent %t", err, tt.permanent)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package mercadolivre
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// PurchaseStatus represents the status of a Purchase.
+type PurchaseStatus string
+
+const (
+	PurchaseStatusStarted PurchaseStatus = "started"
+)
+
+type PurchaseRequest struct {
+	ProductID string `json:"product_id" validate:"required,uuid,should_exist=products.id"`
+	Quantity  int    `validate:"required,gt=0"`
+	Gateway   string `validate:"required,oneof=pagseguro paypal"`
+}
+
+// Validate validates PurchaseRequest.
+func (p PurchaseRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, p)
+}
+
+type PurchaseResponse struct {
+	ID          string
+	RedirectURL string `json:"redirect_url"`
+}
+
+// Purchase represents a single Purchase.
+// ID should be globally unique.
+type Purchase struct {
+	ID        string
+	ProductID string `db:"product_id"`
+	BuyerID   string `db:"buyer_id"`
+	SellerID  string `db:"-"`
+	Quantity  int
+	Price     float32
+	Gateway   Gateway
+	Status    PurchaseStatus
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// PurchasePost starts a Purchase, reserving the Product's stock.
+func (s *service) PurchasePost(ctx context.Context, purchase PurchaseRequest) (*PurchaseResponse, error) {
+	msgError := "service.purchase_post"
+	buyerID, err := userIDFrom(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	id := uuid.New().String()
+	_, err = s.repo.CreatePurchase(ctx, Purchase{
+		ID:        id,
+		ProductID: purchase.ProductID,
+		BuyerID:   buyerID,
+		Quantity:  purchase.Quantity,
+		Gateway:   Gateway(purchase.Gateway),
+		Status:    PurchaseStatusStarted,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		if errors.Is(err, ErrInsufficientStock) {
+			return nil, ValidationErrorsResponse{
+				&ValidationErrorResponse{
+					FailedField: "purchaserequest.quantity",
+					Condition:   ErrInsufficientStock.Error(),
+					ActualValue: strconv.Itoa(purchase.Quantity),
+				},
+			}
+		}
+		return nil, errors.Wrap(err, msgError)
+	}
+	s.metrics.Purchases.With("gateway", purchase.Gateway, "status", string(PurchaseStatusStarted)).Add(1)
+
+	gateway := Gateway(purchase.Gateway)
+	returnURL := fmt.Sprintf("%s/payments/%s/callback", s.baseURL, gateway)
+	redirectURL, err := gateway.RedirectURL(id, returnURL)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	return &PurchaseResponse{
+		ID:          id,
+		RedirectURL: redirectURL,
+	}, nil
+}
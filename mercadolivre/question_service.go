@@ -0,0 +1,95 @@
+package mercadolivre
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type QuestionRequest struct {
+	ProductID string `json:"product_id" validate:"required,uuid,should_exist=products.id"`
+	Title     string `validate:"required,not_blank"`
+}
+
+// Validate validates QuestionRequest.
+func (q QuestionRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, q)
+}
+
+// Question represents a single Product's Question.
+// ID should be globally unique.
+type Question struct {
+	ID        string
+	ProductID string `db:"product_id"`
+	UserID    string `db:"user_id"`
+	Title     string
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// QuestionPost creates Product's question and notifies the Product's owner.
+func (s *service) QuestionPost(ctx context.Context, question QuestionRequest) (string, error) {
+	msgError := "service.question_post"
+	userID, err := userIDFrom(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, msgError)
+	}
+
+	id := uuid.New().String()
+	err = s.repo.CreateQuestion(ctx, Question{
+		ID:        id,
+		ProductID: question.ProductID,
+		UserID:    userID,
+		Title:     question.Title,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", errors.Wrap(err, msgError)
+	}
+
+	if err := s.notifyQuestion(ctx, userID, question); err != nil {
+		s.logger.Errorf("%s: could not notify the product's owner: %v", msgError, err)
+	}
+	return id, nil
+}
+
+// notifyQuestion sends the question to the Product's owner.
+func (s *service) notifyQuestion(ctx context.Context, askerID string, question QuestionRequest) error {
+	product, err := s.repo.Product(ctx, question.ProductID)
+	if err != nil {
+		return err
+	}
+	if product.OwnerID == "" {
+		return nil
+	}
+	owner, err := s.repo.User(ctx, product.OwnerID)
+	if err != nil {
+		return err
+	}
+	asker, err := s.repo.User(ctx, askerID)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, Mail{
+		To:      []string{owner.Name},
+		Subject: fmt.Sprintf("New question about %s", product.Name),
+		Body: fmt.Sprintf(
+			"%s asked a question about %s (/products/%s):\n\n%s",
+			asker.Name,
+			product.Name,
+			question.ProductID,
+			question.Title,
+		),
+	})
+}
+
+type QuestionResponse struct {
+	ID        string
+	UserName  string `json:"user_name" db:"user_name"`
+	Title     string
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,130 @@
+package mercadolivre
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,not_blank"`
+}
+
+// Validate validates RefreshRequest.
+func (r RefreshRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, r)
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required,not_blank"`
+	// All revokes every refresh token of the user, not only the token's family.
+	All bool
+}
+
+// Validate validates LogoutRequest.
+func (l LogoutRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, l)
+}
+
+// RefreshToken represents a single opaque refresh token.
+// Only the token's hash is stored. Every token belongs to a family, which
+// starts at the authentication and grows with each rotation.
+type RefreshToken struct {
+	ID         string
+	FamilyID   string         `db:"family_id"`
+	UserID     string         `db:"user_id"`
+	TokenHash  string         `db:"token_hash"`
+	ExpiresAt  time.Time      `db:"expires_at"`
+	RevokedAt  sql.NullTime   `db:"revoked_at"`
+	ReplacedBy sql.NullString `db:"replaced_by"`
+	CreatedAt  time.Time      `db:"created_at"`
+}
+
+// issueRefreshToken stores a new refresh token of the family and returns it.
+func (s *service) issueRefreshToken(ctx context.Context, userID, familyID string) (id, token string, expiresAt time.Time, err error) {
+	next, token, err := s.newRefreshToken()
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	next.UserID = userID
+	next.FamilyID = familyID
+	if err := s.repo.CreateRefreshToken(ctx, next); err != nil {
+		return "", "", time.Time{}, err
+	}
+	return next.ID, token, next.ExpiresAt, nil
+}
+
+// newRefreshToken generates a refresh token, returning it and its RefreshToken.
+func (s *service) newRefreshToken() (RefreshToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return RefreshToken{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	return RefreshToken{
+		ID:        uuid.New().String(),
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: now.Add(s.jwt.refreshTokenTTL),
+		CreatedAt: now,
+	}, token, nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Refresh exchanges a refresh token for a new access token and a new
+// refresh token. Using a refresh token that was already exchanged revokes
+// its whole family.
+func (s *service) Refresh(ctx context.Context, req RefreshRequest) (*AuthResponse, error) {
+	msgError := "service.refresh"
+	next, token, err := s.newRefreshToken()
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+	stored, err := s.repo.RotateRefreshToken(ctx, hashRefreshToken(req.RefreshToken), next)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			err = fmt.Errorf("%w: refresh token is not valid", ErrAuthFailed)
+		case errors.Is(err, errRefreshTokenExpired):
+			err = fmt.Errorf("%w: refresh token is expired", ErrAuthFailed)
+		case errors.Is(err, errRefreshTokenReused):
+			err = fmt.Errorf("%w: refresh token was reused, its family is revoked", ErrAuthFailed)
+		}
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	res, err := s.createToken(stored.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+	res.RefreshToken = token
+	res.RefreshExpiresAt = &stored.ExpiresAt
+	return res, nil
+}
+
+// Logout revokes the refresh token's family or, if req.All is set, every
+// refresh token of the token's user.
+func (s *service) Logout(ctx context.Context, req LogoutRequest) error {
+	msgError := "service.logout"
+	err := s.repo.RevokeRefreshTokens(ctx, hashRefreshToken(req.RefreshToken), req.All)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			err = fmt.Errorf("%w: refresh token is not valid", ErrAuthFailed)
+		}
+		return errors.Wrap(err, msgError)
+	}
+	return nil
+}
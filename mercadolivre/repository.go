@@ -0,0 +1,182 @@
+package mercadolivre
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// errRefreshTokenExpired is returned when rotating an expired refresh token.
+	errRefreshTokenExpired = errors.New("refresh token is expired")
+	// errRefreshTokenReused is returned when rotating a refresh token which
+	// was already rotated or revoked. Its family is revoked.
+	errRefreshTokenReused = errors.New("refresh token was reused")
+)
+
+// Repository stores everything the service depends on.
+type Repository interface {
+	UserRepository
+	CategoryRepository
+	ProductRepository
+	PurchaseRepository
+	OpinionRepository
+	QuestionRepository
+
+	// Columns returns the table.column names accepted by Exists.
+	Columns(ctx context.Context) (map[string]bool, error)
+	// Exists reports whether a row other than the exceptID one has value in
+	// the table.column column.
+	Exists(ctx context.Context, column, value, exceptID string) (bool, error)
+}
+
+// UserRepository stores the Users and their refresh tokens.
+type UserRepository interface {
+	// CreateUser stores user. ErrAlreadyExists is returned if the name is taken.
+	CreateUser(ctx context.Context, user User) error
+	// User returns the User or ErrNotFound.
+	User(ctx context.Context, id string) (*User, error)
+	// UserByName returns the User named name or ErrNotFound.
+	UserByName(ctx context.Context, name string) (*User, error)
+
+	// CreateRefreshToken stores token.
+	CreateRefreshToken(ctx context.Context, token RefreshToken) error
+	// RotateRefreshToken replaces the refresh token hashed as tokenHash by
+	// next, which joins the replaced token's family and user. The stored next
+	// is returned. ErrNotFound, errRefreshTokenExpired or errRefreshTokenReused
+	// are returned when the token cannot be rotated.
+	RotateRefreshToken(ctx context.Context, tokenHash string, next RefreshToken) (*RefreshToken, error)
+	// RevokeRefreshTokens revokes the family of the refresh token hashed as
+	// tokenHash or, if all is set, every refresh token of its user.
+	// ErrNotFound is returned if there is no such token.
+	RevokeRefreshTokens(ctx context.Context, tokenHash string, all bool) error
+}
+
+// CategoryRepository stores the Categories.
+type CategoryRepository interface {
+	// CreateCategory stores category.
+	CreateCategory(ctx context.Context, category Category) error
+	// CategoryPath returns the Category's ancestors followed by the Category.
+	// It is empty if there is no such Category.
+	CategoryPath(ctx context.Context, id string) ([]Category, error)
+	// CategorySubtree returns the Category and its descendants, ordered by name.
+	// It is empty if there is no such Category.
+	CategorySubtree(ctx context.Context, id string) ([]Category, error)
+	// Categories returns every Category with the number of Products in it,
+	// ordered by name.
+	Categories(ctx context.Context) ([]CategoryListResponse, error)
+	// UpdateCategory updates the non nil fields of update. An empty ParentID
+	// moves the Category to the root. ErrNotFound or ErrCreatesCycle are
+	// returned when the Category cannot be updated.
+	UpdateCategory(ctx context.Context, update CategoryUpdate) error
+	// DeleteCategory deletes the Category, first moving its Products to the
+	// reassignTo Category if it is not empty. ErrNotFound, ErrHasChildren or
+	// ErrInUse are returned when the Category cannot be deleted. As in
+	// Categories, the deleted Products do not count: they are left without
+	// a Category.
+	DeleteCategory(ctx context.Context, id, reassignTo string) error
+}
+
+// ProductRepository stores the Products. Deleted Products are never returned.
+type ProductRepository interface {
+	// CreateProduct stores product with its Features.
+	CreateProduct(ctx context.Context, product Product) error
+	// Product returns the Product with its Features and Images or ErrNotFound.
+	Product(ctx context.Context, id string) (*Product, error)
+	// Products returns a page of Products, newest first, and the number of Products.
+	Products(ctx context.Context, offset, limit int) ([]Product, int, error)
+	// UpdateProduct updates the non nil fields of update. ErrNotFound or
+	// ErrForbidden are returned unless the Product is owned by update.OwnerID.
+	UpdateProduct(ctx context.Context, update ProductUpdate) error
+	// DeleteProduct soft deletes the Product. ErrNotFound or ErrForbidden are
+	// returned unless the Product is owned by ownerID.
+	DeleteProduct(ctx context.Context, ownerID, id string) error
+	// CheckProductOwner returns ErrNotFound or ErrForbidden unless the Product
+	// is owned by ownerID.
+	CheckProductOwner(ctx context.Context, ownerID, id string) error
+	// AddProductImages adds the images' URLs to the Product and returns it.
+	// ErrNotFound or ErrForbidden are returned unless the Product is owned by
+	// ownerID.
+	AddProductImages(ctx context.Context, ownerID, id string, urls []string) (*Product, error)
+	// SearchProducts returns up to search.Limit Products matching search,
+	// ordered by search.Sort and then by ID, following search.After if set.
+	SearchProducts(ctx context.Context, search ProductSearch) ([]ProductSearchResult, error)
+	// SearchFacets counts the Products matching search by each Feature's type
+	// and name, ordered by type, count and name. The types filtered by are
+	// faceted disjunctively: their names are counted among the Products
+	// matching the other filters, so that the counts tell what selecting
+	// another name of the type would add.
+	SearchFacets(ctx context.Context, search ProductSearch) ([]Facet, error)
+}
+
+// PurchaseRepository stores the Purchases and their Transactions.
+type PurchaseRepository interface {
+	// CreatePurchase reserves the Product's stock and stores purchase at the
+	// Product's price, which is returned. ErrInsufficientStock is returned if
+	// the Product does not have purchase.Quantity units.
+	CreatePurchase(ctx context.Context, purchase Purchase) (price float32, err error)
+	// RecordTransaction stores transaction, accepting it unless its Purchase
+	// is already paid, and marks the Purchase as paid when an accepted
+	// transaction succeeded. The Purchase and the stored transaction are
+	// returned. ErrNotFound is returned if there is no such Purchase and
+	// ErrIsNotValid if the Purchase was started at another Gateway, along
+	// with the Purchase.
+	RecordTransaction(ctx context.Context, transaction Transaction) (*Purchase, *Transaction, error)
+}
+
+// OpinionRepository stores the Products' Opinions.
+type OpinionRepository interface {
+	// CreateOpinion stores opinion.
+	CreateOpinion(ctx context.Context, opinion Opinion) error
+	// ProductOpinions returns the Product's Opinions, newest first.
+	ProductOpinions(ctx context.Context, productID string) ([]OpinionResponse, error)
+	// ProductRating returns the average rating of the Product's Opinions,
+	// 0 if there is none, and their number.
+	ProductRating(ctx context.Context, productID string) (average float64, count int, err error)
+}
+
+// QuestionRepository stores the Products' Questions.
+type QuestionRepository interface {
+	// CreateQuestion stores question.
+	CreateQuestion(ctx context.Context, question Question) error
+	// ProductQuestions returns the Product's Questions, oldest first.
+	ProductQuestions(ctx context.Context, productID string) ([]QuestionResponse, error)
+}
+
+// CategoryUpdate holds the Category's fields to update. Nil fields are kept.
+type CategoryUpdate struct {
+	ID       string
+	Name     *string
+	ParentID *string
+}
+
+// ProductUpdate holds the Product's fields to update. Nil fields are kept.
+type ProductUpdate struct {
+	ID         string
+	OwnerID    string
+	Name       *string
+	Price      *float32
+	Amount     *int16
+	Features   []Feature
+	Desc       *string
+	CategoryID *string
+}
+
+// ProductSearch holds the filters, the order and the page of a search.
+type ProductSearch struct {
+	// Query matches the Products' name, description and Features.
+	Query string
+	// CategoryID matches the Products of the Category and its descendants.
+	CategoryID string
+	MinPrice   *float64
+	MaxPrice   *float64
+	MinRating  *float64
+	InStock    bool
+	// Facets maps each Feature's type to the accepted names.
+	Facets map[string][]string
+	// Sort is SortNewest, SortPriceAsc, SortPriceDesc or, with a Query,
+	// SortRelevance.
+	Sort string
+	// After is the last Product of the previous page, nil for the first page.
+	After *searchCursor
+	Limit int
+}
@@ -0,0 +1,157 @@
+package mercadolivre
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	SortNewest    = "newest"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortRelevance = "relevance"
+)
+
+type ProductSearchRequest struct {
+	Query      string   `json:"q" validate:"max=200"`
+	CategoryID string   `json:"category_id" validate:"omitempty,uuid"`
+	MinPrice   *float64 `json:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice   *float64 `json:"max_price" validate:"omitempty,gte=0"`
+	MinRating  *float64 `json:"min_rating" validate:"omitempty,min=1,max=5"`
+	InStock    bool     `json:"in_stock"`
+	Sort       string   `validate:"omitempty,oneof=newest price_asc price_desc relevance"`
+	// Facets filters by Feature, mapping each Feature's type to the accepted names.
+	// Products should match one of the names of every type.
+	Facets map[string][]string `validate:"max=20"`
+	Cursor string
+	Limit  int `validate:"gte=1,lte=100"`
+}
+
+// Validate validates ProductSearchRequest.
+func (p ProductSearchRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, p)
+}
+
+type ProductSearchResponse struct {
+	Products   []ProductSearchResult
+	Facets     []Facet
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Facet counts the matching Products by the names of a Feature's type.
+type Facet struct {
+	Type   string
+	Values []FacetValue
+}
+
+// FacetValue counts the matching Products having a Feature's name.
+type FacetValue struct {
+	Name  string
+	Count int
+}
+
+type ProductSearchResult struct {
+	ProductResponse
+	AverageRating float64 `json:"average_rating" db:"average_rating"`
+	// CursorValue is the Product's value of the sort, as the repository
+	// compares it to ProductSearch.After's.
+	CursorValue string `json:"-" db:"cursor_value"`
+}
+
+// searchCursor points to the last Product of a page.
+type searchCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func (c searchCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSearchCursor(s string) (*searchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c searchCursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ProductSearch searches the Products by text, filtering and sorting them.
+// The results are paginated by keyset: the response's NextCursor is sent
+// back to get the next page.
+func (s *service) ProductSearch(ctx context.Context, req ProductSearchRequest) (*ProductSearchResponse, error) {
+	msgError := "service.product_search"
+	sortBy := req.Sort
+	if sortBy == "" || (sortBy == SortRelevance && req.Query == "") {
+		if req.Query != "" {
+			sortBy = SortRelevance
+		} else {
+			sortBy = SortNewest
+		}
+	}
+
+	var cursor *searchCursor
+	if req.Cursor != "" {
+		var err error
+		cursor, err = decodeSearchCursor(req.Cursor)
+		if err != nil || cursor.Sort != sortBy {
+			return nil, ValidationErrorsResponse{
+				&ValidationErrorResponse{
+					FailedField: "productsearchrequest.cursor",
+					Condition:   ErrIsNotValid.Error(),
+					ActualValue: req.Cursor,
+				},
+			}
+		}
+	}
+
+	search := ProductSearch{
+		Query:      req.Query,
+		CategoryID: req.CategoryID,
+		MinPrice:   req.MinPrice,
+		MaxPrice:   req.MaxPrice,
+		MinRating:  req.MinRating,
+		InStock:    req.InStock,
+		Facets:     req.Facets,
+		Sort:       sortBy,
+		After:      cursor,
+		// One more Product tells whether there is a next page.
+		Limit: req.Limit + 1,
+	}
+	facets, err := s.repo.SearchFacets(ctx, search)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+	products, err := s.repo.SearchProducts(ctx, search)
+	if err != nil {
+		return nil, errors.Wrap(err, msgError)
+	}
+
+	res := &ProductSearchResponse{
+		Products: products,
+		Facets:   facets,
+	}
+	if len(res.Products) > req.Limit {
+		res.Products = res.Products[:req.Limit]
+		last := res.Products[len(res.Products)-1]
+		res.NextCursor = searchCursor{
+			Sort:  sortBy,
+			Value: last.CursorValue,
+			ID:    last.ID,
+		}.encode()
+	}
+	return res, nil
+}
@@ -0,0 +1,126 @@
+package mercadolivre
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// createSearchProducts creates the Products searched by the tests, returning
+// their IDs by name.
+func createSearchProducts(t *testing.T, svc *service) (context.Context, map[string]string) {
+	t.Helper()
+	ctx := contextWithUser(context.Background(), createTestUser(t, svc, "owner@example.com", "secret123"))
+	categoryID, err := svc.CategoryPost(ctx, CategoryRequest{Name: "Books"})
+	if err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+	products := []struct {
+		name   string
+		price  float32
+		amount int16
+		cover  string
+	}{
+		{"Go book", 30, 1, "hard"},
+		{"Rust book", 20, 0, "soft"},
+		{"Go poster", 10, 5, "soft"},
+	}
+	ids := map[string]string{}
+	for _, p := range products {
+		price, amount := p.price, p.amount
+		id, err := svc.ProductPost(ctx, ProductRequest{
+			Name:       p.name,
+			Price:      &price,
+			Amount:     &amount,
+			Features:   []Feature{{Type: "cover", Name: p.cover}, {Type: "language", Name: "en"}},
+			Desc:       "A " + p.name,
+			CategoryID: categoryID,
+		})
+		if err != nil {
+			t.Fatalf("ProductPost: %v", err)
+		}
+		ids[p.name] = id
+	}
+	return ctx, ids
+}
+
+// searchNames returns the names of the found Products.
+func searchNames(res *ProductSearchResponse) []string {
+	names := []string{}
+	for _, p := range res.Products {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestProductSearch(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx, _ := createSearchProducts(t, svc)
+	minPrice := 15.0
+
+	tests := []struct {
+		name string
+		req  ProductSearchRequest
+		want []string
+	}{
+		{"query", ProductSearchRequest{Query: "go", Sort: SortPriceDesc, Limit: 10}, []string{"Go book", "Go poster"}},
+		{"price", ProductSearchRequest{MinPrice: &minPrice, Sort: SortPriceAsc, Limit: 10}, []string{"Rust book", "Go book"}},
+		{"in stock", ProductSearchRequest{InStock: true, Sort: SortPriceDesc, Limit: 10}, []string{"Go book", "Go poster"}},
+		{"facets", ProductSearchRequest{Facets: map[string][]string{"cover": {"soft"}}, Sort: SortPriceAsc, Limit: 10}, []string{"Go poster", "Rust book"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := svc.ProductSearch(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("ProductSearch: %v", err)
+			}
+			if got := searchNames(res); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ProductSearch = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductSearchPages(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx, _ := createSearchProducts(t, svc)
+
+	var names []string
+	req := ProductSearchRequest{Sort: SortPriceDesc, Limit: 2}
+	for page := 0; page < 3; page++ {
+		res, err := svc.ProductSearch(ctx, req)
+		if err != nil {
+			t.Fatalf("ProductSearch: %v", err)
+		}
+		names = append(names, searchNames(res)...)
+		if res.NextCursor == "" {
+			break
+		}
+		req.Cursor = res.NextCursor
+	}
+	if want := []string{"Go book", "Rust book", "Go poster"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("pages = %v, want %v", names, want)
+	}
+}
+
+func TestProductSearchFacets(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx, _ := createSearchProducts(t, svc)
+
+	res, err := svc.ProductSearch(ctx, ProductSearchRequest{
+		Facets: map[string][]string{"cover": {"hard"}},
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatalf("ProductSearch: %v", err)
+	}
+	// The cover is faceted disjunctively: the soft covers are counted
+	// although only the hard one is selected.
+	want := []Facet{
+		{Type: "cover", Values: []FacetValue{{Name: "soft", Count: 2}, {Name: "hard", Count: 1}}},
+		{Type: "language", Values: []FacetValue{{Name: "en", Count: 1}}},
+	}
+	if !reflect.DeepEqual(res.Facets, want) {
+		t.Fatalf("Facets = %+v, want %+v", res.Facets, want)
+	}
+}
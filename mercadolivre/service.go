@@ -2,10 +2,11 @@ package mercadolivre
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jmoiron/sqlx"
@@ -14,106 +15,282 @@ import (
 )
 
 type Request interface {
-	Validate() error
+	Validate(ctx context.Context) error
 }
 
 // Service is a simple CRUD interface for user.
 type Service interface {
 	Auth(ctx context.Context, req AuthRequest) (*AuthResponse, error)
+	CategoriesGet(ctx context.Context) (CategoriesResponse, error)
+	CategoryDelete(ctx context.Context, req CategoryDeleteRequest) error
+	CategoryPatch(ctx context.Context, req CategoryPatchRequest) error
+	CategoryPathGet(ctx context.Context, id string) ([]CategoryResponse, error)
 	CategoryPost(ctx context.Context, req CategoryRequest) (id string, err error)
+	CategoryTreeGet(ctx context.Context, id string) (*CategoryTreeResponse, error)
+	JWKS(ctx context.Context) (*JWKS, error)
+	Logout(ctx context.Context, req LogoutRequest) error
+	OpinionPost(ctx context.Context, req OpinionRequest) (id string, err error)
+	PaymentPost(ctx context.Context, req PaymentRequest) (*PaymentResponse, error)
+	ProductDelete(ctx context.Context, id string) error
+	ProductGet(ctx context.Context, id string) (*ProductDetailResponse, error)
+	ProductImagesPost(ctx context.Context, req ProductImagesRequest) (*ProductResponse, error)
+	ProductPatch(ctx context.Context, req ProductPatchRequest) error
 	ProductPost(ctx context.Context, req ProductRequest) (id string, err error)
+	ProductPut(ctx context.Context, req ProductPutRequest) error
+	ProductSearch(ctx context.Context, req ProductSearchRequest) (*ProductSearchResponse, error)
+	ProductsGet(ctx context.Context, req ProductsRequest) (*ProductsResponse, error)
+	PurchasePost(ctx context.Context, req PurchaseRequest) (*PurchaseResponse, error)
+	QuestionPost(ctx context.Context, req QuestionRequest) (id string, err error)
 	ReAuth(ctx context.Context) (*AuthResponse, error)
+	Refresh(ctx context.Context, req RefreshRequest) (*AuthResponse, error)
+	Shutdown(ctx context.Context) error
 	UserPost(ctx context.Context, req UserRequest) (id string, err error)
 }
 
 type service struct {
 	validate *validator.Validate
-	db       *sqlx.DB
 	logger   Logger
+	storage  Storage
+	mailer   Mailer
+	baseURL  string
+	jwt      *jwtKeys
+	repo     Repository
+	columns  map[string]bool
+	metrics  *Metrics
+
+	purchaseConfirmedHandlers []PurchaseConfirmedHandler
+	eventAttempts             int
+	eventBackoff              time.Duration
+	events                    *events
 }
 
 // NewService creates a service with the necessary dependencies.
 func NewService(cfg Config, logger Logger) (Service, error) {
-	dbx := sqlx.NewDb(cfg.DB, cfg.DriverName)
-	if err := dbx.Ping(); err != nil {
+	var dbx *sqlx.DB
+	if cfg.DB != nil {
+		dbx = sqlx.NewDb(cfg.DB, cfg.DriverName)
+		if err := dbx.Ping(); err != nil {
+			return nil, err
+		}
+	}
+	repo := cfg.Repository
+	if repo == nil {
+		if dbx == nil {
+			return nil, errors.New("db or repository should be configured")
+		}
+		repo = NewPostgresRepository(dbx)
+	}
+
+	if cfg.Storage == nil {
+		return nil, errors.New("storage should be configured")
+	}
+	if cfg.Mailer == nil {
+		return nil, errors.New("mailer should be configured")
+	}
+
+	keys, err := newJWTKeys(cfg.JWT)
+	if err != nil {
 		return nil, err
 	}
 
 	svc := &service{
 		validate: validate,
-		db:       dbx,
 		logger:   logger,
+		storage:  cfg.Storage,
+		mailer:   cfg.Mailer,
+		baseURL:  strings.TrimSuffix(cfg.BaseURL, "/"),
+		jwt:      keys,
+		repo:     repo,
+		metrics:  cfg.Metrics,
+
+		purchaseConfirmedHandlers: cfg.PurchaseConfirmedHandlers,
+		eventAttempts:             cfg.EventAttempts,
+		eventBackoff:              cfg.EventBackoff,
+		events:                    newEvents(),
+	}
+	if svc.metrics == nil {
+		svc.metrics = NewDiscardMetrics()
+	}
+	if svc.eventAttempts <= 0 {
+		svc.eventAttempts = defaultEventAttempts
+	}
+	if svc.eventBackoff <= 0 {
+		svc.eventBackoff = defaultEventBackoff
 	}
 
-	if err := validate.RegisterValidation("should_be_unique", svc.shouldBeUnique); err != nil {
-		logger.Fatal(err)
+	if svc.columns, err = requestColumns(validatedRequests); err != nil {
+		return nil, err
 	}
-	if err := validate.RegisterValidation("should_exist", svc.shouldExist); err != nil {
-		logger.Fatal(err)
+	repoColumns, err := repo.Columns(context.Background())
+	if err != nil {
+		return nil, err
 	}
+	for column := range svc.columns {
+		if !repoColumns[column] {
+			return nil, fmt.Errorf("%w: column %q is not in the repository", ErrIsNotValid, column)
+		}
+	}
+	validatingService.Store(svc)
 
 	return svc, nil
 }
 
-// shouldExist validates if the current field value exists in the repository.
-func (s *service) shouldExist(fl validator.FieldLevel) bool {
-	field := fl.Field()
-	var fieldValue string
-	if field.Kind() == reflect.String {
-		fieldValue = field.String()
-	} else {
-		return false
+// validatedRequests are the requests validated by should_exist and
+// should_be_unique. The columns their tags name are the only ones the
+// validations look up.
+var validatedRequests = []Request{
+	CategoryRequest{},
+	CategoryPatchRequest{},
+	CategoryDeleteRequest{},
+	OpinionRequest{},
+	PaymentRequest{},
+	ProductImagesRequest{},
+	ProductRequest{},
+	ProductPatchRequest{},
+	ProductPutRequest{},
+	PurchaseRequest{},
+	QuestionRequest{},
+	UserRequest{},
+}
+
+// requestColumns returns the table.column params of the should_exist and
+// should_be_unique tags of the requests' fields, nested ones included. A
+// should_be_unique field excepting the row of a field missing from its
+// struct is an error.
+func requestColumns(requests []Request) (map[string]bool, error) {
+	columns := map[string]bool{}
+	var visit func(t reflect.Type) error
+	visit = func(t reflect.Type) error {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			for _, tag := range strings.FieldsFunc(f.Tag.Get("validate"), func(r rune) bool { return r == ',' || r == '|' }) {
+				name, param := splitTag(tag)
+				switch name {
+				case "should_exist":
+					columns[param] = true
+				case "should_be_unique":
+					column, exceptField := uniqueParam(param)
+					if exceptField != "" {
+						if id, ok := t.FieldByName(exceptField); !ok || id.Type.Kind() != reflect.String {
+							return fmt.Errorf("%w: %s.%s: should_be_unique field %q", ErrIsNotValid, t.Name(), f.Name, exceptField)
+						}
+					}
+					columns[column] = true
+				}
+			}
+			if err := visit(f.Type); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
+	for _, req := range requests {
+		if err := visit(reflect.TypeOf(req)); err != nil {
+			return nil, err
+		}
+	}
+	return columns, nil
+}
 
-	fieldName := fl.FieldName()
-	var table string
-	if fieldName == "CategoryID" {
-		table = "categories"
-		fieldName = "id"
+// splitTag splits a validation tag into its name and param.
+func splitTag(tag string) (name, param string) {
+	if i := strings.Index(tag, "="); i >= 0 {
+		return tag[:i], tag[i+1:]
 	}
+	return tag, ""
+}
 
-	query := fmt.Sprintf(`SELECT * FROM %s WHERE %s=$1`, table, fieldName)
-	stmt, err := s.db.Preparex(query)
-	if err != nil {
-		s.logger.Fatal("should_exist validate:", err)
+// uniqueParam splits the should_be_unique param into the table.column and
+// the optional field holding the id of the row being updated.
+func uniqueParam(param string) (column, exceptField string) {
+	fields := strings.Fields(param)
+	if len(fields) == 0 {
+		return "", ""
 	}
+	column = fields[0]
+	if len(fields) > 1 {
+		exceptField = fields[1]
+	}
+	return column, exceptField
+}
 
-	m := make(map[string]interface{})
-	err = stmt.QueryRowx(fieldValue).MapScan(m)
-	return err == nil
+// column checks the table.column param of a validation against the columns
+// of validatedRequests.
+func (s *service) column(param string) error {
+	if !s.columns[param] {
+		return fmt.Errorf("%w: column %q is not valid", ErrInternalServer, param)
+	}
+	return nil
 }
 
-// shouldBeUnique validates if the current field value is unique in the repository.
-func (s *service) shouldBeUnique(fl validator.FieldLevel) bool {
+// validatingService holds the *service the should_exist and should_be_unique
+// validations look up, the last one created. The validator keeps the
+// validations of the structs it parsed, so they are registered only once and
+// find the service here.
+var validatingService atomic.Value
+
+// repositoryValidation returns the validation calling validation on the
+// validating service.
+func repositoryValidation(validation func(s *service, ctx context.Context, fl validator.FieldLevel) bool) validator.FuncCtx {
+	return func(ctx context.Context, fl validator.FieldLevel) bool {
+		svc, ok := validatingService.Load().(*service)
+		if !ok {
+			failValidation(ctx, fmt.Errorf("%w: no service to validate %s with", ErrInternalServer, fl.GetTag()))
+			return false
+		}
+		return validation(svc, ctx, fl)
+	}
+}
+
+// shouldExist validates if the current field value exists in the column
+// given by the param, e.g. should_exist=categories.id.
+func (s *service) shouldExist(ctx context.Context, fl validator.FieldLevel) bool {
 	field := fl.Field()
-	fieldName := strings.ToLower(fl.FieldName())
-	var fieldValue string
-	if field.Kind() == reflect.String {
-		fieldValue = field.String()
-	} else {
+	if field.Kind() != reflect.String {
 		return false
 	}
-
-	var table string
-	switch fl.Top().Type().Name() {
-	case "CategoryRequest":
-		table = "categories"
-	case "UserRequest":
-		table = "users"
+	if err := s.column(fl.Param()); err != nil {
+		failValidation(ctx, err)
+		return false
 	}
-	query := fmt.Sprintf(`SELECT * FROM %s WHERE %s=$1`, table, fieldName)
-	stmt, err := s.db.Preparex(query)
+
+	exists, err := s.repo.Exists(ctx, fl.Param(), field.String(), "")
 	if err != nil {
-		s.logger.Fatal("should_be_unique validate:", err)
+		failValidation(ctx, errors.Wrap(err, "should_exist"))
+		return false
 	}
+	return exists
+}
 
-	m := make(map[string]interface{})
-	err = stmt.QueryRowx(fieldValue).MapScan(m)
+// shouldBeUnique validates if the current field value is unique in the
+// column given by the param, e.g. should_be_unique=users.name. When
+// updating, the param names the field holding the id of the row being
+// updated, which does not count, e.g. should_be_unique=categories.name ID.
+func (s *service) shouldBeUnique(ctx context.Context, fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return false
+	}
+	column, exceptField := uniqueParam(fl.Param())
+	if err := s.column(column); err != nil {
+		failValidation(ctx, err)
+		return false
+	}
+
+	var exceptID string
+	if exceptField != "" {
+		exceptID = fl.Parent().FieldByName(exceptField).String()
+	}
+	exists, err := s.repo.Exists(ctx, column, field.String(), exceptID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return true
-		}
-		s.logger.Fatal("should_be_unique validate:", err)
+		failValidation(ctx, errors.Wrap(err, "should_be_unique"))
+		return false
 	}
-	return false
+	return !exists
 }
@@ -0,0 +1,40 @@
+package mercadolivre
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	jwtKit "github.com/go-kit/kit/auth/jwt"
+)
+
+// newTestService creates a service storing everything in memory.
+func newTestService(t *testing.T) (*service, Repository) {
+	t.Helper()
+	repo := NewMemoryRepository()
+	svc, err := NewService(Config{
+		Repository: repo,
+		JWT:        JWTConfig{Secret: "test-secret"},
+		Storage:    NewMemoryStorage(),
+		Mailer:     NewFakeMailer(NewLogger(ErrorLevel)),
+	}, NewLogger(ErrorLevel))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return svc.(*service), repo
+}
+
+// contextWithUser returns ctx authenticated as the user.
+func contextWithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, jwtKit.JWTClaimsContextKey, &jwt.StandardClaims{Id: userID})
+}
+
+// createTestUser creates the user through the service and returns its ID.
+func createTestUser(t *testing.T, svc *service, name, password string) string {
+	t.Helper()
+	id, err := svc.UserPost(context.Background(), UserRequest{Name: name, Password: password})
+	if err != nil {
+		t.Fatalf("UserPost: %v", err)
+	}
+	return id
+}
@@ -0,0 +1,143 @@
+package mercadolivre
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Storage is a blob storage used to persist uploaded files.
+type Storage interface {
+	// Put stores the content read from r under key and returns its public URL.
+	Put(ctx context.Context, key, contentType string, r io.Reader) (url string, err error)
+	// Delete removes the content stored under key.
+	Delete(ctx context.Context, key string) error
+}
+
+type localStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage creates a Storage that keeps the files in dir.
+// The returned URLs are made by joining baseURL and the file key.
+func NewLocalStorage(dir, baseURL string) (Storage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &localStorage{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Put stores the content read from r in the local filesystem.
+func (l *localStorage) Put(_ context.Context, key, _ string, r io.Reader) (string, error) {
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s", l.baseURL, key), nil
+}
+
+// Delete removes the file stored under key.
+func (l *localStorage) Delete(_ context.Context, key string) error {
+	err := os.Remove(filepath.Join(l.dir, filepath.FromSlash(key)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ServeHTTP serves the stored files. The directories are not listed.
+func (l *localStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.FileServer(filesOnlyFS{http.Dir(l.dir)}).ServeHTTP(w, r)
+}
+
+// filesOnlyFS is a http.FileSystem hiding the directories of fs, so that
+// they are not listed.
+type filesOnlyFS struct {
+	fs http.FileSystem
+}
+
+// Open opens the named file, returning os.ErrNotExist for the directories.
+func (f filesOnlyFS) Open(name string) (http.File, error) {
+	file, err := f.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		_ = file.Close()
+		return nil, os.ErrNotExist
+	}
+	return file, nil
+}
+
+type memoryStorage struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+// NewMemoryStorage creates a Storage that keeps the files in memory.
+// It is intended to be used in tests.
+func NewMemoryStorage() Storage {
+	return &memoryStorage{
+		blobs: make(map[string][]byte),
+	}
+}
+
+// Put stores the content read from r in memory.
+func (m *memoryStorage) Put(_ context.Context, key, _ string, r io.Reader) (string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = b
+	return fmt.Sprintf("memory://%s", key), nil
+}
+
+// Delete removes the content stored under key.
+func (m *memoryStorage) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.blobs, key)
+	return nil
+}
+
+// Get returns a reader for the content stored under key.
+func (m *memoryStorage) Get(key string) (io.Reader, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	b, ok := m.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return bytes.NewReader(b), nil
+}
@@ -0,0 +1,176 @@
+package mercadolivre
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/pt_BR"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ptBRTranslations "github.com/go-playground/validator/v10/translations/pt_BR"
+)
+
+// translator holds the translations of the validation messages.
+// English is the fallback.
+var translator = ut.New(en.New(), en.New(), pt_BR.New())
+
+// languageAliases maps the languages without a translation to a translated one.
+var languageAliases = map[string]string{
+	"pt": "pt_BR",
+}
+
+// customTranslations are the messages of the custom validation tags and of
+// the conditions set by the services, by locale.
+var customTranslations = map[string]map[string]string{
+	"en": {
+		"not_blank":                  "{0} must not be blank",
+		"should_be_future":           "{0} must be in the future",
+		"should_be_unique":           "{0} is already in use",
+		"should_exist":               "{0} must reference an existing record",
+		ErrAlreadyPaid.Error():       "{0} is already paid",
+		ErrCreatesCycle.Error():      "{0} must not create a cycle",
+		ErrHasChildren.Error():       "{0} must not have children",
+		ErrInUse.Error():             "{0} is in use",
+		ErrInsufficientStock.Error(): "{0} is greater than the stock",
+		ErrIsNotValid.Error():        "{0} is not valid",
+		ErrMissingToken.Error():      "{0} is missing",
+	},
+	"pt_BR": {
+		"not_blank":                  "{0} não pode estar em branco",
+		"should_be_future":           "{0} deve estar no futuro",
+		"should_be_unique":           "{0} já está em uso",
+		"should_exist":               "{0} deve referenciar um registro existente",
+		ErrAlreadyPaid.Error():       "{0} já está pago",
+		ErrCreatesCycle.Error():      "{0} não pode criar um ciclo",
+		ErrHasChildren.Error():       "{0} não pode ter filhos",
+		ErrInUse.Error():             "{0} está em uso",
+		ErrInsufficientStock.Error(): "{0} é maior que o estoque",
+		ErrIsNotValid.Error():        "{0} não é válido",
+		ErrMissingToken.Error():      "{0} está ausente",
+	},
+}
+
+// registerTranslations registers the validation messages of every locale into v.
+func registerTranslations(v *validator.Validate) error {
+	enTrans, _ := translator.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(v, enTrans); err != nil {
+		return err
+	}
+	ptBRTrans, _ := translator.GetTranslator("pt_BR")
+	if err := ptBRTranslations.RegisterDefaultTranslations(v, ptBRTrans); err != nil {
+		return err
+	}
+
+	for locale, translations := range customTranslations {
+		trans, _ := translator.GetTranslator(locale)
+		for key, text := range translations {
+			if err := trans.Add(key, text, true); err != nil {
+				return err
+			}
+		}
+	}
+	for _, trans := range []ut.Translator{enTrans, ptBRTrans} {
+		for _, tag := range []string{"not_blank", "should_be_future", "should_be_unique", "should_exist"} {
+			err := v.RegisterTranslation(tag, trans, registerCustomTranslation, translateCustom)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// registerCustomTranslation does nothing: the custom messages are
+// registered from customTranslations.
+func registerCustomTranslation(ut.Translator) error {
+	return nil
+}
+
+func translateCustom(trans ut.Translator, fe validator.FieldError) string {
+	msg, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return msg
+}
+
+type translatorContextKey struct{}
+
+// translatorRequestFunc puts the translator of the request's Accept-Language
+// into the context.
+func translatorRequestFunc(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, translatorContextKey{}, translatorFor(r.Header.Get("Accept-Language")))
+}
+
+func translatorFrom(ctx context.Context) ut.Translator {
+	if trans, ok := ctx.Value(translatorContextKey{}).(ut.Translator); ok {
+		return trans
+	}
+	return translator.GetFallback()
+}
+
+// translatorFor returns the translator of the most preferred language of
+// the Accept-Language header which has a translation.
+func translatorFor(acceptLanguage string) ut.Translator {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var languages []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		if fields[0] == "" {
+			continue
+		}
+		l := language{tag: fields[0], q: 1}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					l.q = q
+				}
+			}
+		}
+		languages = append(languages, l)
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].q > languages[j].q
+	})
+
+	for _, l := range languages {
+		if l.q <= 0 {
+			continue
+		}
+		tag := strings.Replace(l.tag, "-", "_", 1)
+		base := strings.SplitN(tag, "_", 2)[0]
+		for _, locale := range []string{tag, base, languageAliases[base]} {
+			if trans, found := translator.GetTranslator(locale); found && locale != "" {
+				return trans
+			}
+		}
+	}
+	return translator.GetFallback()
+}
+
+// translate sets the messages of v in the trans's language.
+func (v ValidationErrorsResponse) translate(trans ut.Translator) {
+	for _, e := range v {
+		e.Message = e.translate(trans)
+	}
+}
+
+func (v *ValidationErrorResponse) translate(trans ut.Translator) string {
+	if v.fieldError != nil {
+		return v.fieldError.Translate(trans)
+	}
+	field := v.FailedField[strings.LastIndex(v.FailedField, ".")+1:]
+	if msg, err := trans.T(v.Condition, field); err == nil {
+		return msg
+	}
+	return v.Condition
+}
@@ -1,24 +1,54 @@
 package mercadolivre
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	jwtKit "github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
 	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 )
 
+const (
+	// maxImagesMemory is the maximum number of bytes of the uploaded images kept in memory.
+	maxImagesMemory = 32 << 20
+	// maxImagesBody is the maximum number of bytes of an images upload request.
+	maxImagesBody = 64 << 20
+	// maxCallbackBody is the maximum number of bytes of a payment callback.
+	maxCallbackBody = 1 << 20
+)
+
 // MakeHTTPHandler mounts all of the service endpoints into an http.Handler.
 // Useful in a usersvc server.
-func (srv *httpServer) MakeHTTPHandler(svc Service) http.Handler {
+func (srv *httpServer) MakeHTTPHandler(svc Service) (http.Handler, error) {
 	r := mux.NewRouter()
-	e := MakeServerEndpoints(svc)
+	authMdlwr, err := NewAuthMdlwr(srv.jwt)
+	if err != nil {
+		return nil, err
+	}
+	e := MakeServerEndpoints(svc, authMdlwr, CallbackAuthMdlwr(srv.gatewaySecrets), srv.timeouts, srv.metrics)
 
+	// The token is taken from the Authorization header and,
+	// if there is no Bearer token there, from the token cookie.
 	var jwtTokenRequestFunc httptransport.RequestFunc = func(ctx context.Context, r *http.Request) context.Context {
+		if ctx = jwtKit.HTTPToContext()(ctx, r); ctx.Value(jwtKit.JWTTokenContextKey) != nil {
+			return ctx
+		}
+
 		c, err := r.Cookie("token")
 		if err != nil {
 			return ctx
@@ -29,14 +59,35 @@ func (srv *httpServer) MakeHTTPHandler(svc Service) http.Handler {
 	}
 
 	options := []httptransport.ServerOption{
-		httptransport.ServerBefore(jwtTokenRequestFunc),
+		httptransport.ServerBefore(httptransport.PopulateRequestContext, traceIDRequestFunc, translatorRequestFunc, jwtTokenRequestFunc),
 		httptransport.ServerErrorEncoder(srv.encodeError),
 	}
 
+	r.Methods("GET").Path("/.well-known/jwks.json").Handler(httptransport.NewServer(
+		e.JWKSEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	))
+
 	r.Methods("POST").Path("/auth").Handler(httptransport.NewServer(
 		e.AuthEndpoint,
 		decodeAuthPostRequest,
-		encodeAuthResponse,
+		srv.encodeAuthResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/auth/logout").Handler(httptransport.NewServer(
+		e.LogoutEndpoint,
+		decodeLogoutRequest,
+		srv.encodeLogoutResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/auth/refresh").Handler(httptransport.NewServer(
+		e.RefreshEndpoint,
+		decodeRefreshRequest,
+		srv.encodeAuthResponse,
 		options...,
 	))
 
@@ -47,6 +98,48 @@ func (srv *httpServer) MakeHTTPHandler(svc Service) http.Handler {
 		options...,
 	))
 
+	r.Methods("GET").Path("/categories").Handler(httptransport.NewServer(
+		e.CategoriesGetEndpoint,
+		decodeEmptyRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("PATCH").Path("/categories/{id}").Handler(httptransport.NewServer(
+		e.CategoryPatchEndpoint,
+		decodeCategoryPatchRequest,
+		encodeNoContentResponse,
+		options...,
+	))
+
+	r.Methods("DELETE").Path("/categories/{id}").Handler(httptransport.NewServer(
+		e.CategoryDeleteEndpoint,
+		decodeCategoryDeleteRequest,
+		encodeNoContentResponse,
+		options...,
+	))
+
+	r.Methods("GET").Path("/categories/{id}/path").Handler(httptransport.NewServer(
+		e.CategoryPathGetEndpoint,
+		decodeIDRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("GET").Path("/categories/{id}/tree").Handler(httptransport.NewServer(
+		e.CategoryTreeGetEndpoint,
+		decodeIDRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/payments/{gateway}/callback").Handler(httptransport.NewServer(
+		e.PaymentPostEndpoint,
+		decodePaymentPostRequest,
+		encodeResponse,
+		options...,
+	))
+
 	r.Methods("POST").Path("/products").Handler(httptransport.NewServer(
 		e.ProductPostEndpoint,
 		decodeProductPostRequest,
@@ -54,10 +147,80 @@ func (srv *httpServer) MakeHTTPHandler(svc Service) http.Handler {
 		options...,
 	))
 
+	r.Methods("GET").Path("/products").Handler(httptransport.NewServer(
+		e.ProductsGetEndpoint,
+		decodeProductsGetRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("GET").Path("/products/search").Handler(httptransport.NewServer(
+		e.ProductSearchEndpoint,
+		decodeProductSearchRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("GET").Path("/products/{id}").Handler(httptransport.NewServer(
+		e.ProductGetEndpoint,
+		decodeIDRequest,
+		encodeResponse,
+		options...,
+	))
+
+	r.Methods("PUT").Path("/products/{id}").Handler(httptransport.NewServer(
+		e.ProductPutEndpoint,
+		decodeProductPutRequest,
+		encodeNoContentResponse,
+		options...,
+	))
+
+	r.Methods("PATCH").Path("/products/{id}").Handler(httptransport.NewServer(
+		e.ProductPatchEndpoint,
+		decodeProductPatchRequest,
+		encodeNoContentResponse,
+		options...,
+	))
+
+	r.Methods("DELETE").Path("/products/{id}").Handler(httptransport.NewServer(
+		e.ProductDeleteEndpoint,
+		decodeIDRequest,
+		encodeNoContentResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/products/{id}/images").Handler(httptransport.NewServer(
+		e.ProductImagesPostEndpoint,
+		authenticatedDecoder(authMdlwr, decodeProductImagesPostRequest),
+		encodeProductImagesPostResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/products/{id}/opinions").Handler(httptransport.NewServer(
+		e.OpinionPostEndpoint,
+		decodeOpinionPostRequest,
+		encodePostResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/products/{id}/questions").Handler(httptransport.NewServer(
+		e.QuestionPostEndpoint,
+		decodeQuestionPostRequest,
+		encodePostResponse,
+		options...,
+	))
+
+	r.Methods("POST").Path("/purchases").Handler(httptransport.NewServer(
+		e.PurchasePostEndpoint,
+		decodePurchasePostRequest,
+		encodePurchasePostResponse,
+		options...,
+	))
+
 	r.Methods("GET").Path("/reauth").Handler(httptransport.NewServer(
 		e.ReAuthEndpoint,
 		decodeReAuthPostRequest,
-		encodeReAuthResponse,
+		srv.encodeAuthResponse,
 		options...,
 	))
 
@@ -68,13 +231,67 @@ func (srv *httpServer) MakeHTTPHandler(svc Service) http.Handler {
 		options...,
 	))
 
-	return r
+	if srv.standIns {
+		r.Methods("POST").Path("/stand-ins/invoices").Handler(NewInvoiceStandIn(srv.logger))
+		r.Methods("POST").Path("/stand-ins/rankings").Handler(NewRankingStandIn(srv.logger))
+	}
+
+	if h, ok := srv.storage.(http.Handler); ok {
+		r.Methods("GET").PathPrefix("/images/").Handler(http.StripPrefix("/images/", h))
+	}
+
+	return r, nil
+}
+
+// decodeJSON decodes the JSON body into v. A missing or malformed body is
+// reported as ErrMalformedBody.
+func decodeJSON(body io.Reader, v interface{}) error {
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return malformedBody(err)
+	}
+	return nil
+}
+
+// malformedBody reports the decoding errors caused by the body, rather than
+// by reading it, as ErrMalformedBody.
+func malformedBody(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %v", ErrMalformedBody, err)
+	}
+	return err
+}
+
+// bodyTooLarge reports the bodies cut by http.MaxBytesReader as
+// ErrBodyTooLarge.
+func bodyTooLarge(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) || errors.Is(err, multipart.ErrMessageTooLarge) {
+		return fmt.Errorf("%w: %v", ErrBodyTooLarge, err)
+	}
+	return err
+}
+
+// authenticatedDecoder returns dec running after the auth middleware, so
+// that the bodies too costly to decode are only decoded for the
+// authenticated clients. The endpoint is still authenticated by auth.
+func authenticatedDecoder(auth endpoint.Middleware, dec httptransport.DecodeRequestFunc) httptransport.DecodeRequestFunc {
+	authenticate := auth(func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		if _, err := authenticate(ctx, nil); err != nil {
+			return nil, err
+		}
+		return dec(ctx, r)
+	}
 }
 
 func decodeAuthPostRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
 	r = r.WithContext(ctx)
 	var req AuthRequest
-	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+	if e := decodeJSON(r.Body, &req); e != nil {
 		return nil, e
 	}
 	return req, nil
@@ -82,54 +299,355 @@ func decodeAuthPostRequest(ctx context.Context, r *http.Request) (request interf
 
 func decodeCategoryPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	var req CategoryRequest
-	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+	if e := decodeJSON(r.Body, &req); e != nil {
+		return nil, e
+	}
+	return req, nil
+}
+
+func decodeOpinionPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req OpinionRequest
+	if e := decodeJSON(r.Body, &req); e != nil {
+		return nil, e
+	}
+	req.ProductID = mux.Vars(r)["id"]
+	return req, nil
+}
+
+func decodePurchasePostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req PurchaseRequest
+	if e := decodeJSON(r.Body, &req); e != nil {
+		return nil, e
+	}
+	return req, nil
+}
+
+func decodeQuestionPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req QuestionRequest
+	if e := decodeJSON(r.Body, &req); e != nil {
+		return nil, e
+	}
+	req.ProductID = mux.Vars(r)["id"]
+	return req, nil
+}
+
+func decodeEmptyRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return nil, nil
+}
+
+func decodeIDRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return mux.Vars(r)["id"], nil
+}
+
+func decodeCategoryPatchRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req CategoryPatchRequest
+	if e := decodeJSON(r.Body, &req); e != nil {
 		return nil, e
 	}
+	req.ID = mux.Vars(r)["id"]
 	return req, nil
 }
 
+func decodeCategoryDeleteRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return CategoryDeleteRequest{
+		ID:         mux.Vars(r)["id"],
+		ReassignTo: r.URL.Query().Get("reassign_to"),
+	}, nil
+}
+
 func decodeReAuthPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	return nil, nil
 }
 
-func encodeAuthResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+func (srv *httpServer) encodeAuthResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	auth := response.(*AuthResponse)
-	http.SetCookie(w,
-		&http.Cookie{
-			Name:    "token",
-			Value:   auth.TknStr,
-			Expires: auth.ExpiresAt,
-		})
+	http.SetCookie(w, srv.cookie("token", "/", auth.TknStr, auth.ExpiresAt))
+	if auth.RefreshToken != "" && auth.RefreshExpiresAt != nil {
+		c := srv.cookie("refresh_token", "/auth", auth.RefreshToken, *auth.RefreshExpiresAt)
+		c.HttpOnly = true
+		http.SetCookie(w, c)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	return json.NewEncoder(w).Encode(auth)
+}
+
+// cookie creates a cookie with the configured attributes.
+func (srv *httpServer) cookie(name, path, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   srv.cookies.Domain,
+		Expires:  expires,
+		HttpOnly: srv.cookies.HTTPOnly,
+		Secure:   srv.cookies.Secure,
+		SameSite: srv.cookies.SameSite,
+	}
+}
+
+// decodeOptionalBody decodes the JSON body into v, if there is one.
+func decodeOptionalBody(r *http.Request, v interface{}) error {
+	if r.ContentLength != 0 {
+		if e := json.NewDecoder(r.Body).Decode(v); e != nil && e != io.EOF {
+			return malformedBody(e)
+		}
+	}
+	return nil
+}
+
+func decodeRefreshRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req RefreshRequest
+	if e := decodeOptionalBody(r, &req); e != nil {
+		return nil, e
+	}
+	if c, e := r.Cookie("refresh_token"); e == nil && req.RefreshToken == "" {
+		req.RefreshToken = c.Value
+	}
+	return req, nil
+}
+
+func decodeLogoutRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req LogoutRequest
+	if e := decodeOptionalBody(r, &req); e != nil {
+		return nil, e
+	}
+	if c, e := r.Cookie("refresh_token"); e == nil && req.RefreshToken == "" {
+		req.RefreshToken = c.Value
+	}
+	return req, nil
+}
+
+func (srv *httpServer) encodeLogoutResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	token := srv.cookie("token", "/", "", time.Time{})
+	token.MaxAge = -1
+	http.SetCookie(w, token)
+	refreshToken := srv.cookie("refresh_token", "/auth", "", time.Time{})
+	refreshToken.MaxAge = -1
+	refreshToken.HttpOnly = true
+	http.SetCookie(w, refreshToken)
+	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
 
+func decodePaymentPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	// Each gateway sends the status in its own format,
+	// e.g. PayPal sends 1 or 0 and PagSeguro sends SUCESSO or ERRO.
+	var body struct {
+		PurchaseID    string      `json:"purchase_id"`
+		TransactionID string      `json:"transaction_id"`
+		Status        interface{} `json:"status"`
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, maxCallbackBody))
+	if err != nil {
+		return nil, bodyTooLarge(err)
+	}
+	if e := decodeJSON(bytes.NewReader(data), &body); e != nil {
+		return nil, e
+	}
+	req := PaymentRequest{
+		Gateway:       mux.Vars(r)["gateway"],
+		PurchaseID:    body.PurchaseID,
+		TransactionID: body.TransactionID,
+		body:          data,
+		signature:     r.Header.Get(SignatureHeader),
+	}
+	if body.Status != nil {
+		req.Status = fmt.Sprint(body.Status)
+	}
+	return req, nil
+}
+
 func decodeProductPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	var req ProductRequest
-	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+	if e := decodeJSON(r.Body, &req); e != nil {
 		return nil, e
 	}
 	return req, nil
 }
 
-func decodeUserPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
-	var req UserRequest
-	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+func decodeProductImagesPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxImagesBody)
+	if e := r.ParseMultipartForm(maxImagesMemory); e != nil {
+		if e := bodyTooLarge(e); errors.Is(e, ErrBodyTooLarge) {
+			return nil, e
+		}
+		return nil, ValidationErrorsResponse{
+			&ValidationErrorResponse{
+				FailedField: "images",
+				Condition:   e.Error(),
+			},
+		}
+	}
+	// The images spilled over maxImagesMemory are kept in temporary files.
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+	req := ProductImagesRequest{
+		ProductID: mux.Vars(r)["id"],
+	}
+	for _, fh := range r.MultipartForm.File["images"] {
+		f, e := fh.Open()
+		if e != nil {
+			return nil, e
+		}
+		content, e := ioutil.ReadAll(f)
+		_ = f.Close()
+		if e != nil {
+			return nil, e
+		}
+		// The declared type is only checked against the sniffed one, which
+		// is the one trusted.
+		contentType := mediaType(http.DetectContentType(content))
+		declared := mediaType(fh.Header.Get("Content-Type"))
+		if declared != "" && declared != "application/octet-stream" && declared != contentType {
+			return nil, ValidationErrorsResponse{
+				&ValidationErrorResponse{
+					FailedField: "images.content_type",
+					Condition:   fmt.Sprintf("should be %s", contentType),
+					ActualValue: declared,
+				},
+			}
+		}
+		req.Images = append(req.Images, Image{
+			Name:        fh.Filename,
+			ContentType: contentType,
+			Content:     content,
+		})
+	}
+	return req, nil
+}
+
+// mediaType returns the media type of the contentType header value, without
+// its parameters.
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return t
+}
+
+func decodeProductPutRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req ProductPutRequest
+	if e := decodeJSON(r.Body, &req); e != nil {
 		return nil, e
 	}
+	req.ID = mux.Vars(r)["id"]
 	return req, nil
 }
 
-func encodeReAuthResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
-	auth := response.(*AuthResponse)
-	http.SetCookie(w,
-		&http.Cookie{
-			Name:    "token",
-			Value:   auth.TknStr,
-			Expires: auth.ExpiresAt,
-		})
+func decodeProductPatchRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req ProductPatchRequest
+	if e := decodeJSON(r.Body, &req); e != nil {
+		return nil, e
+	}
+	req.ID = mux.Vars(r)["id"]
+	return req, nil
+}
+
+func decodeProductsGetRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	req := ProductsRequest{
+		Page:    1,
+		PerPage: 20,
+	}
+	q := r.URL.Query()
+	if err := queryInt(q, "page", &req.Page); err != nil {
+		return nil, err
+	}
+	if err := queryInt(q, "per_page", &req.PerPage); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func decodeProductSearchRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	q := r.URL.Query()
+	req := ProductSearchRequest{
+		Query:      q.Get("q"),
+		CategoryID: q.Get("category_id"),
+		Sort:       q.Get("sort"),
+		Cursor:     q.Get("cursor"),
+		Limit:      20,
+	}
+	if err := queryFloat(q, "min_price", &req.MinPrice); err != nil {
+		return nil, err
+	}
+	if err := queryFloat(q, "max_price", &req.MaxPrice); err != nil {
+		return nil, err
+	}
+	if err := queryFloat(q, "min_rating", &req.MinRating); err != nil {
+		return nil, err
+	}
+	if v := q.Get("in_stock"); v != "" {
+		if req.InStock, err = strconv.ParseBool(v); err != nil {
+			return nil, queryError("in_stock", v)
+		}
+	}
+	if err := queryInt(q, "limit", &req.Limit); err != nil {
+		return nil, err
+	}
+	// Each facet is sent as type:name, e.g. facet=Cor:Preto.
+	for _, v := range q["facet"] {
+		i := strings.Index(v, ":")
+		if i <= 0 || i == len(v)-1 {
+			return nil, queryError("facet", v)
+		}
+		if req.Facets == nil {
+			req.Facets = map[string][]string{}
+		}
+		typ := v[:i]
+		req.Facets[typ] = append(req.Facets[typ], v[i+1:])
+	}
+	return req, nil
+}
+
+// queryInt parses the query parameter into v, if it is present.
+func queryInt(q url.Values, name string, v *int) error {
+	s := q.Get(name)
+	if s == "" {
+		return nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return queryError(name, s)
+	}
+	*v = i
+	return nil
+}
+
+// queryFloat parses the query parameter into v, if it is present.
+func queryFloat(q url.Values, name string, v **float64) error {
+	s := q.Get(name)
+	if s == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return queryError(name, s)
+	}
+	*v = &f
 	return nil
 }
 
+func queryError(name, value string) error {
+	return ValidationErrorsResponse{
+		&ValidationErrorResponse{
+			FailedField: name,
+			Condition:   ErrIsNotValid.Error(),
+			ActualValue: value,
+		},
+	}
+}
+
+func decodeUserPostRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req UserRequest
+	if e := decodeJSON(r.Body, &req); e != nil {
+		return nil, e
+	}
+	return req, nil
+}
+
 func encodePostResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	id := fmt.Sprintf("/%s", response.(postResponse).ID)
 	w.Header().Set("Location", id)
@@ -137,55 +655,113 @@ func encodePostResponse(ctx context.Context, w http.ResponseWriter, response int
 	return json.NewEncoder(w).Encode(response)
 }
 
+func encodeProductImagesPostResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodePurchasePostResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	purchase := response.(*PurchaseResponse)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Location", purchase.RedirectURL)
+	w.WriteHeader(http.StatusFound)
+	return json.NewEncoder(w).Encode(purchase)
+}
+
+func encodeNoContentResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+// traceIDHeader is the header carrying the request's trace ID.
+const traceIDHeader = "X-Request-ID"
+
+type traceIDContextKey struct{}
+
+// traceIDRequestFunc puts the request's trace ID into the context,
+// generating one if the client did not send it.
+func traceIDRequestFunc(ctx context.Context, r *http.Request) context.Context {
+	traceID := r.Header.Get(traceIDHeader)
+	if traceID == "" {
+		traceID = uuid.New().String()
+	}
+	return context.WithValue(ctx, traceIDContextKey{}, traceID)
+}
+
+func traceIDFrom(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDContextKey{}).(string)
+	return traceID
+}
+
+// Problem is an error response as defined in RFC 7807.
+type Problem struct {
+	Type     string                   `json:"type"`
+	Title    string                   `json:"title"`
+	Status   int                      `json:"status"`
+	Detail   string                   `json:"detail,omitempty"`
+	Instance string                   `json:"instance,omitempty"`
+	Code     ErrorCode                `json:"code"`
+	TraceID  string                   `json:"trace_id,omitempty"`
+	Errors   ValidationErrorsResponse `json:"errors,omitempty"`
+}
+
 func (srv *httpServer) encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	if err == nil {
 		panic("encodeError with nil error")
 	}
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	statusCode := srv.logAndCodeFrom(err)
-	w.WriteHeader(statusCode)
-	if e, ok := err.(ValidationErrorsResponse); ok {
-		_ = json.NewEncoder(w).Encode(map[string]interface{}{
-			"msg":    e.Error(),
-			"errors": e,
-		})
-		return
+	entry := srv.logAndEntryFrom(ctx, err)
+	problem := Problem{
+		Type:    "about:blank",
+		Title:   http.StatusText(entry.Status),
+		Status:  entry.Status,
+		Code:    entry.Code,
+		TraceID: traceIDFrom(ctx),
 	}
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
-		"error": http.StatusText(statusCode),
-	})
-}
-
-func (srv *httpServer) logAndCodeFrom(err error) int {
-	if _, ok := err.(ValidationErrorsResponse); ok {
-		return http.StatusBadRequest
+	if path, ok := ctx.Value(httptransport.ContextKeyRequestPath).(string); ok {
+		problem.Instance = path
 	}
-	if errors.Is(err, ErrAuthFailed) ||
-		errors.Is(err, jwtKit.ErrTokenContextMissing) ||
-		errors.Is(err, jwtKit.ErrUnexpectedSigningMethod) ||
-		errors.Is(err, jwtKit.ErrTokenMalformed) ||
-		errors.Is(err, jwtKit.ErrTokenExpired) ||
-		errors.Is(err, jwtKit.ErrTokenNotActive) {
-		srv.logger.Warn(err)
-		return http.StatusUnauthorized
+	if e, ok := err.(ValidationErrorsResponse); ok {
+		e.translate(translatorFrom(ctx))
+		problem.Detail = e.Error()
+		problem.Errors = e
+	} else if entry.Status != http.StatusInternalServerError {
+		problem.Detail = entry.Err.Error()
 	}
 
-	srv.logStackTrace(err)
-
-	if errors.Is(err, ErrNotFound) {
-		return http.StatusNotFound
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	if problem.TraceID != "" {
+		w.Header().Set(traceIDHeader, problem.TraceID)
 	}
+	w.WriteHeader(entry.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
 
-	return http.StatusInternalServerError
+func (srv *httpServer) logAndEntryFrom(ctx context.Context, err error) catalogueEntry {
+	entry := catalogueEntryFrom(err)
+	switch {
+	case entry.Status >= http.StatusInternalServerError:
+		srv.logStackTrace(ctx, err)
+	case entry.Status == http.StatusUnauthorized || entry.Status == http.StatusForbidden:
+		srv.logger.Warnw(err.Error(), "code", entry.Code, "trace_id", traceIDFrom(ctx))
+	default:
+		srv.logger.Infow(err.Error(), "code", entry.Code, "trace_id", traceIDFrom(ctx))
+	}
+	return entry
 }
 
-func (srv *httpServer) logStackTrace(err error) {
+func (srv *httpServer) logStackTrace(ctx context.Context, err error) {
 	type stackTracer interface {
 		StackTrace() errors.StackTrace
 	}
 	if e, ok := err.(stackTracer); ok {
-		srv.logger.Errorf("%s%+v", err, e.StackTrace())
+		srv.logger.Errorw(fmt.Sprintf("%s%+v", err, e.StackTrace()), "trace_id", traceIDFrom(ctx))
 		return
 	}
-	srv.logger.Error(err)
+	srv.logger.Errorw(err.Error(), "trace_id", traceIDFrom(ctx))
 }
@@ -0,0 +1,90 @@
+package mercadolivre
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jwtKit "github.com/go-kit/kit/auth/jwt"
+)
+
+func TestDecodeJSONMalformedBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"empty", ""},
+		{"syntax", `{"name": }`},
+		{"truncated", `{"name": "a"`},
+		{"type", `{"name": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v struct {
+				Name string `json:"name"`
+			}
+			err := decodeJSON(strings.NewReader(tt.body), &v)
+			if !errors.Is(err, ErrMalformedBody) {
+				t.Fatalf("decodeJSON error = %v, want %v", err, ErrMalformedBody)
+			}
+			if entry := catalogueEntryFrom(err); entry.Status != http.StatusBadRequest || entry.Code != CodeMalformedBody {
+				t.Errorf("catalogue entry = %d %s, want %d %s", entry.Status, entry.Code, http.StatusBadRequest, CodeMalformedBody)
+			}
+		})
+	}
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("images", "image.png")
+	_, _ = part.Write(bytes.Repeat([]byte{0}, 1<<10))
+	_ = mw.Close()
+
+	r := httptest.NewRequest("POST", "/products/id/images", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	r.Body = http.MaxBytesReader(nil, r.Body, 1<<9)
+	err := bodyTooLarge(r.ParseMultipartForm(1 << 9))
+	if entry := catalogueEntryFrom(err); entry.Status != http.StatusRequestEntityTooLarge || entry.Code != CodeBodyTooLarge {
+		t.Fatalf("catalogue entry = %d %s, want %d %s: %v", entry.Status, entry.Code, http.StatusRequestEntityTooLarge, CodeBodyTooLarge, err)
+	}
+
+	r = httptest.NewRequest("POST", "/payments/paypal/callback", bytes.NewReader(make([]byte, maxCallbackBody+1)))
+	if _, err := decodePaymentPostRequest(context.Background(), r); !errors.Is(err, ErrBodyTooLarge) {
+		t.Fatalf("decodePaymentPostRequest error = %v, want %v", err, ErrBodyTooLarge)
+	}
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.n += len(p)
+	return len(p), nil
+}
+
+func TestAuthenticatedDecoder(t *testing.T) {
+	auth, err := NewAuthMdlwr(JWTConfig{Secret: "test-secret"})
+	if err != nil {
+		t.Fatalf("NewAuthMdlwr: %v", err)
+	}
+	body := &countingReader{}
+	decode := authenticatedDecoder(auth, decodeProductImagesPostRequest)
+
+	r := httptest.NewRequest("POST", "/products/id/images", body)
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	ctx := context.WithValue(context.Background(), jwtKit.JWTTokenContextKey, "not a token")
+	if _, err := decode(ctx, r); catalogueEntryFrom(err).Status != http.StatusUnauthorized {
+		t.Fatalf("decode error = %v, want the request unauthenticated", err)
+	}
+	if body.n != 0 {
+		t.Fatalf("%d bytes of the body were read, want none before authenticating", body.n)
+	}
+}
@@ -11,7 +11,7 @@ import (
 )
 
 type UserRequest struct {
-	Name     string `validate:"required,not_blank,email,should_be_unique"`
+	Name     string `validate:"required,not_blank,email,should_be_unique=users.name"`
 	Password string `validate:"required,not_blank,min=6"`
 }
 
@@ -22,8 +22,8 @@ type UserResponse struct {
 }
 
 // Validate validates UserRequest.
-func (u UserRequest) Validate() error {
-	return Validate(u)
+func (u UserRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, u)
 }
 
 // User represents a single user.
@@ -37,27 +37,22 @@ type User struct {
 
 // UserPost creates user.
 func (s *service) UserPost(ctx context.Context, user UserRequest) (string, error) {
-	stmt, err := s.db.Prepare("INSERT INTO users (id, name, password, created_at) VALUES ($1, $2, $3, $4)")
 	msgError := "service.user_post"
+	hash, err := hashPassword(user.Password)
 	if err != nil {
 		return "", errors.Wrap(err, msgError)
 	}
-	now := time.Now()
-	layout := "2006-01-02 15:04:05"
 	id := uuid.New().String()
-	var hash string
-	hash, err = hashPassword(user.Password)
-	if err != nil {
-		return "", errors.Wrap(err, msgError)
-	}
-	_, err = stmt.Exec(
-		id,
-		user.Name,
-		hash,
-		now.Format(layout))
+	err = s.repo.CreateUser(ctx, User{
+		ID:        id,
+		Name:      user.Name,
+		Password:  hash,
+		CreatedAt: time.Now(),
+	})
 	if err != nil {
 		return "", errors.Wrap(err, msgError)
 	}
+	s.metrics.UsersCreated.Add(1)
 	return id, nil
 }
 
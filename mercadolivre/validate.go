@@ -1,6 +1,8 @@
 package mercadolivre
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"reflect"
 	"strings"
@@ -14,12 +16,22 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
 	if err := validate.RegisterValidation("not_blank", validators.NotBlank); err != nil {
 		log.Fatalln(err)
 	}
 	if err := validate.RegisterValidation("should_be_future", shouldBeFuture); err != nil {
 		log.Fatalln(err)
 	}
+	if err := validate.RegisterValidationCtx("should_be_unique", repositoryValidation((*service).shouldBeUnique)); err != nil {
+		log.Fatalln(err)
+	}
+	if err := validate.RegisterValidationCtx("should_exist", repositoryValidation((*service).shouldExist)); err != nil {
+		log.Fatalln(err)
+	}
+	if err := registerTranslations(validate); err != nil {
+		log.Fatalln(err)
+	}
 }
 
 type ValidationErrorsResponse []*ValidationErrorResponse
@@ -31,12 +43,20 @@ func (v ValidationErrorsResponse) Error() string {
 type ValidationErrorResponse struct {
 	FailedField string `json:"failed_field,omitempty"`
 	Condition   string `json:"condition"`
+	// Message is the condition in the client's language.
+	Message     string `json:"message,omitempty"`
 	ActualValue string `json:"actual_value,omitempty"`
+
+	fieldError validator.FieldError
 }
 
 //Validate validates a struct
-func Validate(iface interface{}) error {
-	err := validate.Struct(iface)
+func Validate(ctx context.Context, iface interface{}) error {
+	failure := &validationFailure{}
+	err := validate.StructCtx(context.WithValue(ctx, validationFailureContextKey{}, failure), iface)
+	if failure.err != nil {
+		return fmt.Errorf("validate: %w", failure.err)
+	}
 	var errs ValidationErrorsResponse
 	if err != nil {
 		if fieldError, ok := err.(validator.ValidationErrors); ok {
@@ -46,9 +66,10 @@ func Validate(iface interface{}) error {
 					actualValue = vStr
 				}
 				element := ValidationErrorResponse{
-					FailedField: strings.ToLower(v.StructNamespace()),
+					FailedField: strings.ToLower(v.Namespace()),
 					Condition:   v.Tag(),
 					ActualValue: actualValue,
+					fieldError:  v,
 				}
 				errs = append(errs, &element)
 			}
@@ -60,6 +81,32 @@ func Validate(iface interface{}) error {
 	return nil
 }
 
+// jsonFieldName names the fields as the clients send them, by their json
+// name. The fields without one keep their Go name.
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// validationFailure holds the error which prevented a validation from
+// checking its field, such as a database error.
+type validationFailure struct {
+	err error
+}
+
+type validationFailureContextKey struct{}
+
+// failValidation reports the error which prevented a validation from
+// checking its field. Validate returns it instead of the validation errors.
+func failValidation(ctx context.Context, err error) {
+	if failure, ok := ctx.Value(validationFailureContextKey{}).(*validationFailure); ok && failure.err == nil {
+		failure.err = err
+	}
+}
+
 // shouldBeFuture validates if the current field is time.Time and is after time.Now().
 func shouldBeFuture(fl validator.FieldLevel) bool {
 	field := fl.Field()
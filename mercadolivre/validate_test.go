@@ -0,0 +1,151 @@
+package mercadolivre
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// hasFailedField reports whether err is a ValidationErrorsResponse with a
+// failure of field.
+func hasFailedField(err error, field string) bool {
+	var errs ValidationErrorsResponse
+	if !errors.As(err, &errs) {
+		return false
+	}
+	for _, e := range errs {
+		if e.FailedField == field {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidate(t *testing.T) {
+	type request struct {
+		Name      string    `validate:"required,not_blank"`
+		ExpiresAt time.Time `validate:"should_be_future"`
+	}
+	tests := []struct {
+		name  string
+		req   request
+		field string
+	}{
+		{"valid", request{Name: "name", ExpiresAt: time.Now().Add(time.Hour)}, ""},
+		{"blank", request{Name: "  ", ExpiresAt: time.Now().Add(time.Hour)}, "request.name"},
+		{"past", request{Name: "name", ExpiresAt: time.Now().Add(-time.Hour)}, "request.expiresat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(context.Background(), tt.req)
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Validate error = %v, want nil", err)
+				}
+				return
+			}
+			if !hasFailedField(err, tt.field) {
+				t.Fatalf("Validate error = %v, want a failure of %s", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestShouldBeUnique(t *testing.T) {
+	svc, _ := newTestService(t)
+	createTestUser(t, svc, "taken@example.com", "secret123")
+
+	err := UserRequest{Name: "taken@example.com", Password: "secret123"}.Validate(context.Background())
+	if !hasFailedField(err, "userrequest.name") {
+		t.Fatalf("Validate error = %v, want a failure of userrequest.name", err)
+	}
+	if err := (UserRequest{Name: "free@example.com", Password: "secret123"}).Validate(context.Background()); err != nil {
+		t.Fatalf("Validate error = %v, want nil", err)
+	}
+}
+
+func TestShouldExist(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := contextWithUser(context.Background(), createTestUser(t, svc, "user@example.com", "secret123"))
+	categoryID, err := svc.CategoryPost(ctx, CategoryRequest{Name: "Books"})
+	if err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+
+	if err := (CategoryRequest{Name: "Novels", ParentID: categoryID}).Validate(ctx); err != nil {
+		t.Fatalf("Validate error = %v, want nil", err)
+	}
+	err = CategoryRequest{Name: "Novels", ParentID: "b0f3f1b6-6a43-4c1e-9a4f-1b2d3c4e5f60"}.Validate(ctx)
+	if !hasFailedField(err, "categoryrequest.parent_id") {
+		t.Fatalf("Validate error = %v, want a failure of categoryrequest.parent_id", err)
+	}
+}
+
+func TestShouldBeUniqueExceptsUpdatedRow(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := contextWithUser(context.Background(), createTestUser(t, svc, "user@example.com", "secret123"))
+	booksID, err := svc.CategoryPost(ctx, CategoryRequest{Name: "Books"})
+	if err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+	if _, err := svc.CategoryPost(ctx, CategoryRequest{Name: "Music"}); err != nil {
+		t.Fatalf("CategoryPost: %v", err)
+	}
+
+	books, music := "Books", "Music"
+	if err := (CategoryPatchRequest{ID: booksID, Name: &books}).Validate(ctx); err != nil {
+		t.Fatalf("Validate error = %v, want nil", err)
+	}
+	err = CategoryPatchRequest{ID: booksID, Name: &music}.Validate(ctx)
+	if !hasFailedField(err, "categorypatchrequest.name") {
+		t.Fatalf("Validate error = %v, want a failure of categorypatchrequest.name", err)
+	}
+}
+
+func TestRequestColumns(t *testing.T) {
+	columns, err := requestColumns(validatedRequests)
+	if err != nil {
+		t.Fatalf("requestColumns: %v", err)
+	}
+	for _, column := range []string{"categories.id", "categories.name", "products.id", "purchases.id", "users.name"} {
+		if !columns[column] {
+			t.Errorf("requestColumns is missing %s", column)
+		}
+	}
+	if len(columns) != 5 {
+		t.Errorf("requestColumns = %v, want only the columns of the tags", columns)
+	}
+
+	if _, err := requestColumns([]Request{intIDRequest{}}); !errors.Is(err, ErrIsNotValid) {
+		t.Fatalf("requestColumns error = %v, want %v", err, ErrIsNotValid)
+	}
+}
+
+// intIDRequest excepts the row of an ID which is not a string.
+type intIDRequest struct {
+	ID   int
+	Name string `validate:"should_be_unique=users.name ID"`
+}
+
+func (r intIDRequest) Validate(ctx context.Context) error {
+	return Validate(ctx, r)
+}
+
+func TestValidateNamesTheJSONFields(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := contextWithUser(context.Background(), createTestUser(t, svc, "user@example.com", "secret123"))
+
+	err := CategoryRequest{Name: "Novels", ParentID: "b0f3f1b6-6a43-4c1e-9a4f-1b2d3c4e5f60"}.Validate(ctx)
+	var errs ValidationErrorsResponse
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("Validate error = %v, want a failure of parent_id", err)
+	}
+	errs.translate(translator.GetFallback())
+	if errs[0].FailedField != "categoryrequest.parent_id" {
+		t.Errorf("FailedField = %q, want categoryrequest.parent_id", errs[0].FailedField)
+	}
+	if want := "parent_id must reference an existing record"; errs[0].Message != want {
+		t.Errorf("Message = %q, want %q", errs[0].Message, want)
+	}
+}
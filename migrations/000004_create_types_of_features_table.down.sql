@@ -1 +1 @@
-DROP TABLE features_types;
\ No newline at end of file
+DROP TABLE types_of_features;
\ No newline at end of file
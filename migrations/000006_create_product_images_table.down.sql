@@ -0,0 +1 @@
+DROP TABLE product_images;
\ No newline at end of file
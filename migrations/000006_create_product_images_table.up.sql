@@ -0,0 +1,6 @@
+CREATE TABLE product_images (
+  id uuid NOT NULL PRIMARY KEY,
+  product_id uuid REFERENCES products (id),
+  url VARCHAR(2048) NOT NULL,
+  created_at timestamp
+);
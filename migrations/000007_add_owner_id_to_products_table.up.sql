@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN owner_id uuid REFERENCES users (id);
@@ -0,0 +1 @@
+DROP TABLE opinions;
\ No newline at end of file
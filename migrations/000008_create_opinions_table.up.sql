@@ -0,0 +1,9 @@
+CREATE TABLE opinions (
+  id uuid NOT NULL PRIMARY KEY,
+  product_id uuid REFERENCES products (id),
+  user_id uuid REFERENCES users (id),
+  rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+  title VARCHAR(255) NOT NULL,
+  description VARCHAR(500) NOT NULL,
+  created_at timestamp
+);
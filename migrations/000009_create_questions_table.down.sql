@@ -0,0 +1 @@
+DROP TABLE questions;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE questions (
+  id uuid NOT NULL PRIMARY KEY,
+  product_id uuid REFERENCES products (id),
+  user_id uuid REFERENCES users (id),
+  title VARCHAR(255) NOT NULL,
+  created_at timestamp
+);
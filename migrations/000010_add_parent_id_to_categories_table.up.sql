@@ -0,0 +1,2 @@
+ALTER TABLE categories ADD COLUMN parent_id uuid REFERENCES categories (id);
+ALTER TABLE categories ADD CONSTRAINT categories_parent_id_check CHECK (parent_id <> id);
@@ -0,0 +1 @@
+DROP TABLE purchases;
\ No newline at end of file
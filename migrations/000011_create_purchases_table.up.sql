@@ -0,0 +1,10 @@
+CREATE TABLE purchases (
+  id uuid NOT NULL PRIMARY KEY,
+  product_id uuid REFERENCES products (id),
+  buyer_id uuid REFERENCES users (id),
+  quantity INTEGER NOT NULL CHECK (quantity > 0),
+  price NUMERIC(9,2) NOT NULL,
+  gateway VARCHAR(255) NOT NULL,
+  status VARCHAR(255) NOT NULL,
+  created_at timestamp
+);
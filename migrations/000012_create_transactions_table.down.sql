@@ -0,0 +1 @@
+DROP TABLE transactions;
\ No newline at end of file
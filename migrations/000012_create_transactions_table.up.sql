@@ -0,0 +1,10 @@
+CREATE TABLE transactions (
+  id uuid NOT NULL PRIMARY KEY,
+  purchase_id uuid REFERENCES purchases (id),
+  gateway VARCHAR(255) NOT NULL,
+  gateway_transaction_id VARCHAR(255) NOT NULL,
+  status VARCHAR(255) NOT NULL,
+  accepted BOOLEAN NOT NULL,
+  created_at timestamp
+);
+CREATE UNIQUE INDEX transactions_paid_purchase_id_idx ON transactions (purchase_id) WHERE status = 'success' AND accepted;
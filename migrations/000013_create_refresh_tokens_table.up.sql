@@ -0,0 +1,12 @@
+CREATE TABLE refresh_tokens (
+  id uuid NOT NULL PRIMARY KEY,
+  family_id uuid NOT NULL,
+  user_id uuid REFERENCES users (id),
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  expires_at timestamp NOT NULL,
+  revoked_at timestamp,
+  replaced_by uuid REFERENCES refresh_tokens (id),
+  created_at timestamp
+);
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
@@ -0,0 +1,11 @@
+ALTER TABLE products ADD COLUMN search tsvector;
+UPDATE products p SET search =
+  setweight(to_tsvector('portuguese', coalesce(p.name, '')), 'A') ||
+  setweight(to_tsvector('portuguese', coalesce(p.description, '')), 'B') ||
+  setweight(to_tsvector('portuguese', coalesce((
+    SELECT string_agg(t.type || ' ' || f.name || ' ' || f.details, ' ')
+    FROM types_of_features t
+    JOIN features f ON f.type_id = t.id
+    WHERE t.product_id = p.id
+  ), '')), 'C');
+CREATE INDEX products_search_idx ON products USING GIN (search);
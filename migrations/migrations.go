@@ -0,0 +1,10 @@
+// Package migrations embeds the database migrations, kept as golang-migrate
+// style NNNNNN_name.up.sql and NNNNNN_name.down.sql files.
+package migrations
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS